@@ -17,6 +17,7 @@ package advisor
 import "github.com/hybridapp-io/ham-placement/pkg/advisor/alphabet"
 
 func init() {
-	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
-	AddToAdvisorsFunc = append(AddToAdvisorsFunc, alphabet.Add)
+	if err := DefaultRegistry.Register(alphabet.AdvisorName, alphabet.Add); err != nil {
+		panic(err)
+	}
 }
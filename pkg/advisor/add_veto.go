@@ -17,6 +17,7 @@ package advisor
 import "github.com/hybridapp-io/ham-placement/pkg/advisor/veto"
 
 func init() {
-	// AddToManagerFuncs is a list of functions to create controllers and add them to a manager.
-	AddToAdvisorsFunc = append(AddToAdvisorsFunc, veto.Add)
+	if err := DefaultRegistry.Register(veto.AdvisorName, veto.Add); err != nil {
+		panic(err)
+	}
 }
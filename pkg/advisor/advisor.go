@@ -19,10 +19,19 @@ import (
 )
 
 // AddToAdvisorsFunc is a list of functions to add all Controllers to the Manager
+//
+// Deprecated: register advisors in DefaultRegistry, or in a Registry of an embedded placement engine
 var AddToAdvisorsFunc []func(manager.Manager) error
 
-// AddToManager adds all Controllers to the Manager
+// DefaultRegistry holds the advisors built into the operator
+var DefaultRegistry = NewRegistry()
+
+// AddToManager adds the advisors of DefaultRegistry and AddToAdvisorsFunc to the Manager
 func AddToManager(m manager.Manager) error {
+	if err := DefaultRegistry.AddToManager(m); err != nil {
+		return err
+	}
+
 	for _, f := range AddToAdvisorsFunc {
 		if err := f(m); err != nil {
 			return err
@@ -27,6 +27,11 @@ type objectReferenceIndex struct {
 	Items []*corev1.ObjectReference
 }
 
+const (
+	// AdvisorName is the name of the advisor in the placement rule spec
+	AdvisorName = "alphabet"
+)
+
 var defaultScore = int16(corev1alpha1.DefaultScore)
 
 func (oi objectReferenceIndex) Len() int {
@@ -47,7 +52,7 @@ func (oi objectReferenceIndex) Swap(x, y int) {
 	oi.Items[x], oi.Items[y] = oi.Items[y], oi.Items[x]
 }
 
-func (r *ReconcileAlphabetAdvisor) Recommend(instance *corev1alpha1.PlacementRule) []corev1alpha1.ScoredObjectReference {
+func (r *alphabetRecommender) Recommend(instance *corev1alpha1.PlacementRule, _ *corev1alpha1.Advisor) ([]corev1alpha1.ScoredObjectReference, error) {
 	ori := objectReferenceIndex{}
 
 	for _, or := range instance.Status.Candidates {
@@ -76,5 +81,5 @@ func (r *ReconcileAlphabetAdvisor) Recommend(instance *corev1alpha1.PlacementRul
 		}
 	}
 
-	return rec
+	return rec, nil
 }
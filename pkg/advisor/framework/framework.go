@@ -0,0 +1,297 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package framework runs advisors of placement rules, an advisor only implements the Recommender interface
+package framework
+
+import (
+	"context"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/klog"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// Recommender recommends candidates of placement rules as one advisor
+type Recommender interface {
+	// Name is the advisor name in the spec of the placement rules
+	Name() string
+	// Type is the advisor type the recommendations are meant for
+	Type() corev1alpha1.AdvisorType
+	// Recommend returns the recommended candidates of the rule, an error is reported in the PlacementRecommendation of the
+	// advisor instance and retried with backoff.
+	// The error of a predicate advisor holds the decisions of the rule, a priority advisor is ignored for the round.
+	Recommend(instance *corev1alpha1.PlacementRule, advisor *corev1alpha1.Advisor) ([]corev1alpha1.ScoredObjectReference, error)
+}
+
+// Framework is the controller of a Recommender: it watches placement rules, skips rules not advised by the recommender
+// or already recommended for their latest spec and round, writes recommendations and records metrics.
+// Recommendations are written as PlacementRecommendations owned by the rules, advisors only read placement rules.
+type Framework struct {
+	recommender Recommender
+	engine      string
+	rules       labels.Selector
+}
+
+// Option configures a Framework
+type Option func(*Framework)
+
+// WithPlacementRecommendations makes the advisor create a PlacementRecommendation owned by each rule.
+//
+// Deprecated: advisors always write PlacementRecommendations, the option has no effect
+func WithPlacementRecommendations() Option {
+	return func(*Framework) {}
+}
+
+// WithEngine scopes the advisor controller to a placement engine, the controller is named after the engine
+func WithEngine(name string) Option {
+	return func(f *Framework) {
+		f.engine = name
+	}
+}
+
+// WithRuleSelector makes the advisor watch the rules selected by the labels of its placement engine only
+func WithRuleSelector(selector labels.Selector) Option {
+	return func(f *Framework) {
+		f.rules = selector
+	}
+}
+
+// New returns the framework of a recommender
+func New(recommender Recommender, opts ...Option) *Framework {
+	f := &Framework{recommender: recommender}
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	return f
+}
+
+// Add creates the advisor controller and adds it to the Manager, opts scope it to the placement engine adding it
+func (f *Framework) Add(mgr manager.Manager, opts ...Option) error {
+	scoped := *f
+
+	for _, opt := range opts {
+		opt(&scoped)
+	}
+
+	return scoped.add(mgr, scoped.newReconciler(mgr))
+}
+
+// newReconciler returns a new reconcile.Reconciler
+func (f *Framework) newReconciler(mgr manager.Manager) reconcile.Reconciler {
+	return &reconcileAdvisor{
+		client:      mgr.GetClient(),
+		scheme:      mgr.GetScheme(),
+		recommender: f.recommender,
+	}
+}
+
+// add adds a new Controller to mgr with r as the reconcile.Reconciler
+func (f *Framework) add(mgr manager.Manager, r reconcile.Reconciler) error {
+	// advisors of several engines in the same manager have their own controllers
+	name := f.recommender.Name() + "-advisor"
+	if f.engine != "" {
+		name = f.engine + "-" + name
+	}
+
+	// Create a new controller
+	c, err := controller.New(name, mgr, controller.Options{Reconciler: r})
+	if err != nil {
+		return err
+	}
+
+	var predicates []predicate.Predicate
+
+	if f.rules != nil {
+		predicates = append(predicates, predicate.NewPredicateFuncs(func(meta metav1.Object, _ runtime.Object) bool {
+			return f.rules.Matches(labels.Set(meta.GetLabels()))
+		}))
+	}
+
+	// Watch for changes to primary resource PlacementRule
+	return c.Watch(&source.Kind{Type: &corev1alpha1.PlacementRule{}}, &handler.EnqueueRequestForObject{}, predicates...)
+}
+
+// blank assignment to verify that reconcileAdvisor implements reconcile.Reconciler
+var _ reconcile.Reconciler = &reconcileAdvisor{}
+
+// reconcileAdvisor recommends candidates of a PlacementRule with a recommender
+type reconcileAdvisor struct {
+	client      client.Client
+	scheme      *runtime.Scheme
+	recommender Recommender
+}
+
+// Reconcile recommends the candidates of a PlacementRule once per spec generation and round of candidates
+func (r *reconcileAdvisor) Reconcile(request reconcile.Request) (reconcile.Result, error) {
+	name := r.recommender.Name()
+	start := time.Now()
+
+	recommended, err := r.advise(request)
+
+	switch {
+	case errors.IsNotFound(err):
+		// Request object not found, could have been deleted after reconcile request.
+		return reconcile.Result{}, nil
+	case err != nil:
+		klog.Error("Advisor ", name, " failed to provide recommendation for ", request.NamespacedName, ", error: ", err)
+		observeRecommendation(name, resultError, start)
+	case recommended:
+		observeRecommendation(name, resultRecommended, start)
+	}
+
+	return reconcile.Result{}, err
+}
+
+// advise writes the recommendations of the current round of a PlacementRule for every instance of the advisor in the rule,
+// returns true if a recommendation is written
+func (r *reconcileAdvisor) advise(request reconcile.Request) (bool, error) {
+	// Fetch the PlacementRule instance
+	instance := &corev1alpha1.PlacementRule{}
+
+	if err := r.client.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+		return false, err
+	}
+
+	// stale rules are advised once the placement rule controller observes their latest spec
+	advisors := advisorutils.GetAdvisors(instance, r.recommender.Name())
+
+	var (
+		recommended bool
+		errs        []error
+	)
+
+	for i := range advisors {
+		var (
+			ok  bool
+			err error
+		)
+
+		// priority advisors wait for the predicate advisors of the round
+		if !advisorutils.InStage(instance, &advisors[i]) {
+			continue
+		}
+
+		ok, err = r.createRecommendation(instance, &advisors[i])
+
+		recommended = recommended || ok
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return recommended, utilerrors.NewAggregate(errs)
+}
+
+// recommend returns the recommendation of the recommender for the rule
+func (r *reconcileAdvisor) recommend(instance *corev1alpha1.PlacementRule, adv *corev1alpha1.Advisor) ([]corev1alpha1.ScoredObjectReference, error) {
+	name := r.recommender.Name()
+
+	if adv.Type != nil && *adv.Type != r.recommender.Type() {
+		klog.Warning("Advisor ", name, " recommends as ", r.recommender.Type(), " for placement rule ",
+			instance.Namespace+"/"+instance.Name, " declaring it as ", *adv.Type)
+	}
+
+	klog.Info("Advisor ", name, " advising placementRule ", instance.Namespace+"/"+instance.Name, " as ", adv.InstanceID())
+
+	return r.recommender.Recommend(instance, adv)
+}
+
+// createRecommendation creates or updates the PlacementRecommendation of an advisor instance for the rule
+func (r *reconcileAdvisor) createRecommendation(instance *corev1alpha1.PlacementRule, adv *corev1alpha1.Advisor) (bool, error) {
+	name := r.recommender.Name()
+	id := adv.InstanceID()
+
+	prec := &corev1alpha1.PlacementRecommendation{}
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: advisorutils.RecommendationName(instance.Name, id)}
+
+	err := r.client.Get(context.TODO(), key, prec)
+	if err != nil && !errors.IsNotFound(err) {
+		return false, err
+	}
+
+	found := err == nil
+	current := found && prec.Spec.Generation == instance.Status.ObservedGeneration && prec.Spec.Round == instance.Status.Round
+
+	// reported errors are retried in the same round
+	if current && prec.Spec.Error == "" {
+		return false, nil
+	}
+
+	rec, rerr := r.recommend(instance, adv)
+	if rerr != nil && current && prec.Spec.Error == rerr.Error() {
+		return false, rerr
+	}
+
+	prec.Name = key.Name
+	prec.Namespace = key.Namespace
+	prec.Labels = map[string]string{
+		corev1alpha1.LabelPlacementRule: advisorutils.LabelValue(instance.Name),
+		corev1alpha1.LabelAdvisor:       advisorutils.LabelValue(id),
+	}
+	prec.Spec = corev1alpha1.PlacementRecommendationSpec{
+		PlacementRef:   corev1.LocalObjectReference{Name: instance.Name},
+		Advisor:        id,
+		Generation:     instance.Status.ObservedGeneration,
+		Round:          instance.Status.Round,
+		Recommendation: rec,
+	}
+
+	if rerr != nil {
+		prec.Spec.Error = rerr.Error()
+	}
+
+	// recommendations are garbage collected with their rule
+	if err = controllerutil.SetOwnerReference(instance, prec, r.scheme); err != nil {
+		return false, err
+	}
+
+	if found {
+		err = r.client.Update(context.TODO(), prec)
+	} else {
+		err = r.client.Create(context.TODO(), prec)
+	}
+
+	if rerr != nil {
+		if err != nil {
+			klog.Error("Advisor ", name, " failed to report error for placementRule ", instance.Namespace+"/"+instance.Name,
+				", error: ", err)
+		}
+
+		return false, rerr
+	}
+
+	return err == nil, err
+}
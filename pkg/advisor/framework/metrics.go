@@ -0,0 +1,49 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package framework
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// results of the recommendation metrics
+const (
+	resultRecommended = "recommended"
+	resultError       = "error"
+)
+
+var (
+	recommendationsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "placement_advisor_recommendations_total",
+		Help: "Total number of recommendations written or failed per advisor",
+	}, []string{"advisor", "result"})
+
+	recommendationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name: "placement_advisor_recommendation_duration_seconds",
+		Help: "Time to recommend and write the recommendation of a placement rule per advisor",
+	}, []string{"advisor", "result"})
+)
+
+func init() {
+	metrics.Registry.MustRegister(recommendationsTotal, recommendationDuration)
+}
+
+func observeRecommendation(advisor, result string, start time.Time) {
+	recommendationsTotal.WithLabelValues(advisor, result).Inc()
+	recommendationDuration.WithLabelValues(advisor, result).Observe(time.Since(start).Seconds())
+}
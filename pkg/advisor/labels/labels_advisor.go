@@ -0,0 +1,158 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/ghodss/yaml"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+const (
+	// AdvisorName is the name of the advisor in the placement rule spec
+	AdvisorName = "labels"
+)
+
+// labelRules are the weighted label preferences of the advisor rules, as "selector:weight",
+// e.g. "tier=gold:100" or "region in (us-east,us-west):60"
+type labelRules struct {
+	Preferences []string `json:"preferences"`
+}
+
+// preference is a parsed label preference
+type preference struct {
+	selector labels.Selector
+	weight   int64
+}
+
+// Recommend scores every candidate by the weights of the label preferences its target matches,
+// normalized over the score range of the advisor: all preferences score the maximum, none the minimum
+func (r *labelsRecommender) Recommend(instance *corev1alpha1.PlacementRule, adv *corev1alpha1.Advisor) ([]corev1alpha1.ScoredObjectReference, error) {
+	var prefs []preference
+
+	if adv.Rules != nil && len(adv.Rules.Raw) != 0 {
+		var err error
+
+		if prefs, err = parseRules(adv.Rules.Raw); err != nil {
+			return nil, fmt.Errorf("failed to parse label preferences: %w", err)
+		}
+	}
+
+	var total int64
+	for _, pref := range prefs {
+		total += pref.weight
+	}
+
+	minScore, maxScore := advisorutils.ScoreRange(adv)
+
+	rec := make([]corev1alpha1.ScoredObjectReference, 0, len(instance.Status.Candidates))
+
+	for _, or := range instance.Status.Candidates {
+		target, err := r.getTarget(or)
+		if err != nil {
+			return nil, err
+		}
+
+		var matched int64
+
+		for _, pref := range prefs {
+			if target != nil && pref.selector.Matches(labels.Set(target.GetLabels())) {
+				matched += pref.weight
+			}
+		}
+
+		score := minScore
+		if total > 0 {
+			score = minScore + int16((int64(maxScore)-int64(minScore))*matched/total)
+		}
+
+		rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or, Score: &score})
+	}
+
+	return rec, nil
+}
+
+// getTarget reads the target of a candidate, nil if it is deleted
+func (r *labelsRecommender) getTarget(or corev1.ObjectReference) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(or.APIVersion, or.Kind))
+
+	err := r.reader.Get(context.TODO(), types.NamespacedName{Namespace: or.Namespace, Name: or.Name}, obj)
+	if errors.IsNotFound(err) {
+		// the candidates of the rule are regenerated without it
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
+
+// parseRules parses the label preferences, rejecting unknown fields, invalid selectors and weights
+func parseRules(raw []byte) ([]preference, error) {
+	data, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := &labelRules{}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	if err = decoder.Decode(rules); err != nil {
+		return nil, err
+	}
+
+	prefs := make([]preference, 0, len(rules.Preferences))
+
+	for _, p := range rules.Preferences {
+		// label keys and values have no colon, the weight follows the last one
+		i := strings.LastIndex(p, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("preference %q has no weight, expected selector:weight", p)
+		}
+
+		selector, err := labels.Parse(strings.TrimSpace(p[:i]))
+		if err != nil {
+			return nil, fmt.Errorf("preference %q has an invalid selector: %w", p, err)
+		}
+
+		weight, err := strconv.ParseInt(strings.TrimSpace(p[i+1:]), 10, 16)
+		if err != nil || weight <= 0 {
+			return nil, fmt.Errorf("preference %q has an invalid weight, expected a positive integer", p)
+		}
+
+		prefs = append(prefs, preference{selector: selector, weight: weight})
+	}
+
+	return prefs, nil
+}
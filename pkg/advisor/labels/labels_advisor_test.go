@@ -0,0 +1,107 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		rules   string
+		weights []int64
+		matches map[string]string // labels matched by the first preference
+		wantErr string
+	}{
+		{
+			name:    "weighted preferences",
+			rules:   `{"preferences":["tier=gold:100","region in (us-east,us-west):60"]}`,
+			weights: []int64{100, 60},
+			matches: map[string]string{"tier": "gold"},
+		},
+		{
+			name:    "yaml preferences with spaces",
+			rules:   "preferences:\n- \"tier = gold : 5\"\n",
+			weights: []int64{5},
+			matches: map[string]string{"tier": "gold"},
+		},
+		{
+			name:    "no preference",
+			rules:   `{"preferences":[]}`,
+			weights: []int64{},
+		},
+		{
+			name:    "missing weight",
+			rules:   `{"preferences":["tier=gold"]}`,
+			wantErr: "has no weight",
+		},
+		{
+			name:    "zero weight",
+			rules:   `{"preferences":["tier=gold:0"]}`,
+			wantErr: "invalid weight",
+		},
+		{
+			name:    "negative weight",
+			rules:   `{"preferences":["tier=gold:-10"]}`,
+			wantErr: "invalid weight",
+		},
+		{
+			name:    "weight out of range",
+			rules:   `{"preferences":["tier=gold:40000"]}`,
+			wantErr: "invalid weight",
+		},
+		{
+			name:    "invalid selector",
+			rules:   `{"preferences":["tier in gold:10"]}`,
+			wantErr: "invalid selector",
+		},
+		{
+			name:    "unknown field",
+			rules:   `{"preferences":["tier=gold:10"],"weights":[10]}`,
+			wantErr: "unknown field",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			prefs, err := parseRules([]byte(tt.rules))
+			if tt.wantErr != "" {
+				g.Expect(err).To(MatchError(ContainSubstring(tt.wantErr)))
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+
+			weights := make([]int64, 0, len(prefs))
+			for _, pref := range prefs {
+				weights = append(weights, pref.weight)
+			}
+
+			g.Expect(weights).To(Equal(tt.weights))
+
+			if tt.matches != nil {
+				g.Expect(prefs[0].selector.Matches(labels.Set(tt.matches))).To(BeTrue())
+			}
+		})
+	}
+}
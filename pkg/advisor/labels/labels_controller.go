@@ -0,0 +1,46 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labels
+
+import (
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/hybridapp-io/ham-placement/pkg/advisor/framework"
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// Add creates the labels advisor controller and adds it to the Manager. The Manager will set fields on the Controller
+// and Start it when the Manager is Started.
+func Add(mgr manager.Manager, opts ...framework.Option) error {
+	// targets are read from the shared informers of the manager cache, like the placement rule controller reads them
+	return framework.New(&labelsRecommender{reader: mgr.GetCache()}).Add(mgr, opts...)
+}
+
+// blank assignment to verify that labelsRecommender implements framework.Recommender
+var _ framework.Recommender = &labelsRecommender{}
+
+// labelsRecommender recommends the candidates of placement rules as the labels advisor
+type labelsRecommender struct {
+	reader client.Reader
+}
+
+func (r *labelsRecommender) Name() string {
+	return AdvisorName
+}
+
+func (r *labelsRecommender) Type() corev1alpha1.AdvisorType {
+	return corev1alpha1.AdvisorTypePriority
+}
@@ -0,0 +1,87 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package advisor
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+
+	"github.com/hybridapp-io/ham-placement/pkg/advisor/framework"
+)
+
+// AddFunc creates an advisor controller and adds it to a manager, options scope it to a placement engine
+type AddFunc func(manager.Manager, ...framework.Option) error
+
+// Registry holds the advisor controllers of a placement engine by advisor name
+type Registry struct {
+	mu    sync.RWMutex
+	funcs map[string]AddFunc
+}
+
+// NewRegistry returns an empty advisor registry
+func NewRegistry() *Registry {
+	return &Registry{funcs: make(map[string]AddFunc)}
+}
+
+// Register adds the advisor controller of name to the registry
+func (r *Registry) Register(name string, add AddFunc) error {
+	if name == "" || add == nil {
+		return fmt.Errorf("advisor name and add function are required")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.funcs[name]; ok {
+		return fmt.Errorf("advisor %s is already registered", name)
+	}
+
+	r.funcs[name] = add
+
+	return nil
+}
+
+// Names returns the sorted names of the registered advisors
+func (r *Registry) Names() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.funcs))
+	for name := range r.funcs {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// AddToManager adds the registered advisor controllers to the manager, in name order, scoped by opts
+func (r *Registry) AddToManager(m manager.Manager, opts ...framework.Option) error {
+	for _, name := range r.Names() {
+		r.mu.RLock()
+		add := r.funcs[name]
+		r.mu.RUnlock()
+
+		if err := add(m, opts...); err != nil {
+			return fmt.Errorf("failed to add advisor %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
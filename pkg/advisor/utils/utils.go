@@ -15,11 +15,17 @@
 package utils
 
 import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
 	"strings"
 
 	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 var (
@@ -27,6 +33,9 @@ var (
 		UID: types.UID(rune(0)),
 	}
 
+	// EmptyRecommendatation was recommended by advisors recommending no candidate, its reference matches no candidate.
+	//
+	// Deprecated: an empty recommendation recommends no candidate, the controller removes this reference from recommendations
 	EmptyRecommendatation = []corev1.ObjectReference{
 		zeroObjectReference,
 	}
@@ -36,20 +45,107 @@ func GenKey(or corev1.ObjectReference) string {
 	return string(or.UID)
 }
 
+// IsEmptyRecommendationReference returns true for the reference of the deprecated EmptyRecommendatation
+func IsEmptyRecommendationReference(or corev1.ObjectReference) bool {
+	return or == zeroObjectReference
+}
+
+// NonNilRecommendation returns rec, or an empty recommendation if rec is nil. An empty recommendation is written
+// to the status as an empty list recommending no candidate, a nil one would be dropped as null.
+func NonNilRecommendation(rec []corev1alpha1.ScoredObjectReference) corev1alpha1.Recommendation {
+	if rec == nil {
+		return corev1alpha1.Recommendation{}
+	}
+
+	return rec
+}
+
 func MakeRecommendation(instance *corev1alpha1.PlacementRule, advisorName string, rec []corev1alpha1.ScoredObjectReference) {
 	if instance.Status.Recommendations == nil {
 		instance.Status.Recommendations = make(map[string]corev1alpha1.Recommendation)
 	}
 
-	instance.Status.Recommendations[advisorName] = rec
+	instance.Status.Recommendations[advisorName] = NonNilRecommendation(rec)
+
+	// echo the round the recommendation is made for, the controller discards it in later rounds
+	if instance.Status.RecommendationRounds == nil {
+		instance.Status.RecommendationRounds = make(map[string]int64)
+	}
+
+	instance.Status.RecommendationRounds[advisorName] = instance.Status.Round
 }
 
-func IsSameRecommendation(instance *corev1alpha1.PlacementRule, advisorName string, rec []corev1alpha1.ScoredObjectReference) bool {
-	if instance.Status.Recommendations == nil && len(rec) == 0 {
-		return true
+// RecommendationPatch returns a JSON merge patch of the status writing the recommendation of an advisor instance only,
+// stamped with the current round of the rule. The patch merges the entry of the advisor instance into the status maps,
+// so that advisors never overwrite each other or the decision status of the controller, even when they write the first
+// entries of a round concurrently. The controller discards recommendations stamped with another round.
+// The status maps are read for advisors not built on the advisor framework, which writes PlacementRecommendations.
+func RecommendationPatch(instance *corev1alpha1.PlacementRule, advisorID string, rec []corev1alpha1.ScoredObjectReference) (client.Patch, error) {
+	return statusMergePatch(map[string]interface{}{
+		"recommendations":      map[string]interface{}{advisorID: NonNilRecommendation(rec)},
+		"recommendationRounds": map[string]interface{}{advisorID: instance.Status.Round},
+	})
+}
+
+// ErrorPatch returns a JSON merge patch of the status reporting the error of an advisor instance failing to recommend,
+// merged like the patch of RecommendationPatch
+func ErrorPatch(instance *corev1alpha1.PlacementRule, advisorID, message string) (client.Patch, error) {
+	return statusMergePatch(map[string]interface{}{
+		"advisorErrors": map[string]interface{}{
+			advisorID: corev1alpha1.AdvisorError{Round: instance.Status.Round, Message: message},
+		},
+	})
+}
+
+// statusMergePatch returns a JSON merge patch of the status fields
+func statusMergePatch(status map[string]interface{}) (client.Patch, error) {
+	data, err := json.Marshal(map[string]interface{}{"status": status})
+	if err != nil {
+		return nil, err
+	}
+
+	return client.RawPatch(types.MergePatchType, data), nil
+}
+
+// RecommendationName returns the name of the PlacementRecommendation of an advisor instance for a placement rule,
+// the rule name suffixed with a hash of the rule name and advisor instance id, unique for every pair of them
+func RecommendationName(ruleName, advisorID string) string {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(ruleName))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(advisorID))
+
+	suffix := fmt.Sprintf("-%016x", h.Sum64())
+
+	if len(ruleName)+len(suffix) > validation.DNS1123SubdomainMaxLength {
+		ruleName = strings.TrimRight(ruleName[:validation.DNS1123SubdomainMaxLength-len(suffix)], "-.")
+	}
+
+	return ruleName + suffix
+}
+
+// LabelValue returns value if it is a valid label value, a hash of it otherwise.
+// Labels of PlacementRecommendations are hints, rule names and advisor instance ids are matched on their spec.
+func LabelValue(value string) string {
+	if len(validation.IsValidLabelValue(value)) == 0 {
+		return value
 	}
 
-	return EqualRecommendations(instance.Status.Recommendations[advisorName], rec)
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(value))
+
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+// IsSameRecommendation returns true if the advisor instance already recommended the candidates of rec,
+// an empty recommendation is not the same as no recommendation
+func IsSameRecommendation(instance *corev1alpha1.PlacementRule, advisorID string, rec []corev1alpha1.ScoredObjectReference) bool {
+	current, ok := instance.Status.Recommendations[advisorID]
+	if !ok {
+		return false
+	}
+
+	return EqualRecommendations(current, rec)
 }
 
 func EqualRecommendations(src, dst []corev1alpha1.ScoredObjectReference) bool {
@@ -99,7 +195,21 @@ func EqualDecisions(src, dst []corev1.ObjectReference) bool {
 	return true
 }
 
+// GetAdvisor returns the first advisor of the rule implemented by the named advisor.
+//
+// Deprecated: an advisor can be used several times in a rule with different instance ids, use GetAdvisors
 func GetAdvisor(instance *corev1alpha1.PlacementRule, advisorName string) *corev1alpha1.Advisor {
+	advisors := GetAdvisors(instance, advisorName)
+	if len(advisors) == 0 {
+		return nil
+	}
+
+	return &advisors[0]
+}
+
+// GetAdvisors returns the advisors of the rule implemented by the named advisor, advisor names are case-insensitive.
+// Recommendations are keyed by the instance id of each advisor, the rule is advised once the controller observes its latest spec.
+func GetAdvisors(instance *corev1alpha1.PlacementRule, advisorName string) []corev1alpha1.Advisor {
 	if instance == nil || advisorName == "" {
 		return nil
 	}
@@ -109,18 +219,52 @@ func GetAdvisor(instance *corev1alpha1.PlacementRule, advisorName string) *corev
 		return nil
 	}
 
+	var advisors []corev1alpha1.Advisor
+
 	// check if advisor is in the list
 	for _, adv := range instance.Spec.Advisors {
 		if strings.EqualFold(adv.Name, advisorName) {
-			return adv.DeepCopy()
+			advisors = append(advisors, *adv.DeepCopy())
 		}
 	}
 
-	return nil
+	return advisors
 }
 
-func Recommended(instance *corev1alpha1.PlacementRule, advisorName string) bool {
-	if instance == nil || advisorName == "" {
+// InStage returns true if the advisor instance is asked to recommend in the current stage of the round of the rule:
+// predicate advisors recommend first, priority advisors only score the candidates left by them.
+// Every advisor is asked in rules without stages.
+func InStage(instance *corev1alpha1.PlacementRule, adv *corev1alpha1.Advisor) bool {
+	if instance == nil || adv == nil {
+		return false
+	}
+
+	if instance.Status.Stage == corev1alpha1.AdvisorTypeUnknown {
+		return true
+	}
+
+	advtype := corev1alpha1.AdvisorTypePriority
+	if adv.Type != nil {
+		advtype = *adv.Type
+	}
+
+	return advtype == instance.Status.Stage
+}
+
+// ReportedError returns true if the advisor instance already reported the error message for the current round of the rule
+func ReportedError(instance *corev1alpha1.PlacementRule, advisorID, message string) bool {
+	if instance == nil {
+		return false
+	}
+
+	aerr, ok := instance.Status.AdvisorErrors[advisorID]
+
+	return ok && aerr.Round == instance.Status.Round && aerr.Message == message
+}
+
+// Recommended returns true if the advisor instance recommended for the current round of the rule
+func Recommended(instance *corev1alpha1.PlacementRule, advisorID string) bool {
+	if instance == nil || advisorID == "" {
 		return false
 	}
 
@@ -128,7 +272,27 @@ func Recommended(instance *corev1alpha1.PlacementRule, advisorName string) bool
 		instance.Status.Recommendations = make(map[string]corev1alpha1.Recommendation)
 	}
 
-	_, recommended := instance.Status.Recommendations[advisorName]
+	_, recommended := instance.Status.Recommendations[advisorID]
+	if !recommended {
+		return false
+	}
+
+	// recommendations stamped with an earlier round are stale
+	round, stamped := instance.Status.RecommendationRounds[advisorID]
+
+	return !stamped || round == instance.Status.Round
+}
+
+// ScoreRange returns the score range of a priority advisor, invalid ranges fall back to the default one
+func ScoreRange(adv *corev1alpha1.Advisor) (int16, int16) {
+	if adv.ScoreRange == nil {
+		return corev1alpha1.DefaultMinScore, corev1alpha1.DefaultMaxScore
+	}
+
+	if adv.ScoreRange.Max <= adv.ScoreRange.Min {
+		klog.Warning("Invalid score range ", *adv.ScoreRange, " of advisor ", adv.InstanceID(), ", using the default range")
+		return corev1alpha1.DefaultMinScore, corev1alpha1.DefaultMaxScore
+	}
 
-	return recommended
+	return adv.ScoreRange.Min, adv.ScoreRange.Max
 }
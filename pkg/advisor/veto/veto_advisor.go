@@ -15,16 +15,19 @@
 package veto
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
 	"github.com/ghodss/yaml"
 	corev1 "k8s.io/api/core/v1"
-	"k8s.io/klog"
 
-	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
 	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
 )
 
 const (
-	advisorName = "veto"
+	// AdvisorName is the name of the advisor in the placement rule spec
+	AdvisorName = "veto"
 )
 
 var defaultScore = int16(corev1alpha1.DefaultScore)
@@ -33,7 +36,7 @@ type vetoRules struct {
 	Resources []corev1.ObjectReference `json:"resources"`
 }
 
-func (r *ReconcileVetoAdvisor) doRecommend(candidates, bl []corev1.ObjectReference) []corev1.ObjectReference {
+func (r *vetoRecommender) doRecommend(candidates, bl []corev1.ObjectReference) []corev1.ObjectReference {
 	var rec []corev1.ObjectReference
 
 	for _, or := range candidates {
@@ -65,31 +68,38 @@ func (r *ReconcileVetoAdvisor) doRecommend(candidates, bl []corev1.ObjectReferen
 	return rec
 }
 
-func (r *ReconcileVetoAdvisor) Recommend(instance *corev1alpha1.PlacementRule, vetoadv *corev1alpha1.Advisor) []corev1alpha1.ScoredObjectReference {
+func (r *vetoRecommender) Recommend(instance *corev1alpha1.PlacementRule, vetoadv *corev1alpha1.Advisor) ([]corev1alpha1.ScoredObjectReference, error) {
 	if vetoadv.Rules == nil || (vetoadv.Rules.Object == nil && len(vetoadv.Rules.Raw) == 0) {
-		return r.getScoredObjectReferences(instance.Status.Candidates)
+		return r.getScoredObjectReferences(instance.Status.Candidates), nil
 	}
 
 	vetorules := &vetoRules{}
 
 	if len(vetoadv.Rules.Raw) != 0 {
-		err := yaml.Unmarshal(vetoadv.Rules.Raw, vetorules)
-		if err != nil {
-			klog.Error("Failed to parse veto objects ", err)
-			return r.getScoredObjectReferences(instance.Status.Candidates)
+		if err := parseRules(vetoadv.Rules.Raw, vetorules); err != nil {
+			// recommending every candidate would disable the veto
+			return nil, fmt.Errorf("failed to parse veto rules: %w", err)
 		}
 	}
 
-	rec := r.doRecommend(instance.Status.Candidates, vetorules.Resources)
+	// vetoing every candidate is an empty recommendation
+	return r.getScoredObjectReferences(r.doRecommend(instance.Status.Candidates, vetorules.Resources)), nil
+}
 
-	if len(rec) == 0 {
-		rec = advisorutils.EmptyRecommendatation
+// parseRules parses the veto rules, rejecting unknown fields so that a misspelled field does not disable the veto
+func parseRules(raw []byte, vetorules *vetoRules) error {
+	data, err := yaml.YAMLToJSON(raw)
+	if err != nil {
+		return err
 	}
 
-	return r.getScoredObjectReferences(rec)
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	return decoder.Decode(vetorules)
 }
 
-func (r *ReconcileVetoAdvisor) getScoredObjectReferences(references []corev1.ObjectReference) []corev1alpha1.ScoredObjectReference {
+func (r *vetoRecommender) getScoredObjectReferences(references []corev1.ObjectReference) []corev1alpha1.ScoredObjectReference {
 	rec := make([]corev1alpha1.ScoredObjectReference, len(references))
 	for i, or := range references {
 
@@ -15,109 +15,28 @@
 package veto
 
 import (
-	"context"
-
-	"k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/klog"
-
-	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller"
-	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
-	"sigs.k8s.io/controller-runtime/pkg/reconcile"
-	"sigs.k8s.io/controller-runtime/pkg/source"
 
-	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
+	"github.com/hybridapp-io/ham-placement/pkg/advisor/framework"
 	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
 )
 
-/**
-* USER ACTION REQUIRED: This is a scaffold file intended for the user to modify with their own Controller
-* business logic.  Delete these comments after modifying this file.*
- */
-
-// Add creates a new PlacementRule Controller and adds it to the Manager. The Manager will set fields on the Controller
+// Add creates the veto advisor controller and adds it to the Manager. The Manager will set fields on the Controller
 // and Start it when the Manager is Started.
-func Add(mgr manager.Manager) error {
-	return add(mgr, newReconciler(mgr))
-}
-
-// newReconciler returns a new reconcile.Reconciler
-func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	rec := &ReconcileVetoAdvisor{
-		client: mgr.GetClient(),
-		scheme: mgr.GetScheme(),
-	}
-
-	return rec
+func Add(mgr manager.Manager, opts ...framework.Option) error {
+	return framework.New(&vetoRecommender{}).Add(mgr, opts...)
 }
 
-// add adds a new Controller to mgr with r as the reconcile.Reconciler
-func add(mgr manager.Manager, r reconcile.Reconciler) error {
-	// Create a new controller
-	c, err := controller.New("veto-advisor", mgr, controller.Options{Reconciler: r})
-	if err != nil {
-		return err
-	}
+// blank assignment to verify that vetoRecommender implements framework.Recommender
+var _ framework.Recommender = &vetoRecommender{}
 
-	// Watch for changes to primary resource PlacementRule
-	err = c.Watch(&source.Kind{Type: &corev1alpha1.PlacementRule{}}, &handler.EnqueueRequestForObject{})
-	if err != nil {
-		return err
-	}
+// vetoRecommender recommends the candidates of placement rules as the veto advisor
+type vetoRecommender struct{}
 
-	return nil
+func (r *vetoRecommender) Name() string {
+	return AdvisorName
 }
 
-// blank assignment to verify that ReconcileVetoAdvisor implements reconcile.Reconciler
-var _ reconcile.Reconciler = &ReconcileVetoAdvisor{}
-
-// ReconcileVetoAdvisor reconciles a PlacementRule object
-type ReconcileVetoAdvisor struct {
-	// This client, initialized using mgr.Client() above, is a split client
-	// that reads objects from the cache and writes to the apiserver
-	client client.Client
-	scheme *runtime.Scheme
-}
-
-// Reconcile reads that state of the cluster for a PlacementRule object and makes changes based on the state read
-// and what is in the PlacementRule.Spec
-// Note:
-// The Controller will requeue the Request to be processed again if the returned error is non-nil or
-// Result.Requeue is true, otherwise upon completion it will remove the work from the queue.
-func (r *ReconcileVetoAdvisor) Reconcile(request reconcile.Request) (reconcile.Result, error) {
-	// Fetch the PlacementRule instance
-	instance := &corev1alpha1.PlacementRule{}
-
-	err := r.client.Get(context.TODO(), request.NamespacedName, instance)
-	if err != nil {
-		if errors.IsNotFound(err) {
-			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
-			return reconcile.Result{}, nil
-		}
-		// Error reading the object - requeue the request.
-		return reconcile.Result{}, err
-	}
-
-	advisor := advisorutils.GetAdvisor(instance, advisorName)
-	if advisor == nil || advisorutils.Recommended(instance, advisorName) {
-		return reconcile.Result{}, nil
-	}
-
-	rec := r.Recommend(instance, advisor)
-	klog.Info("Veto advising placementRule ", request.NamespacedName, " targets: ", rec)
-
-	if !advisorutils.IsSameRecommendation(instance, advisorName, rec) {
-		advisorutils.MakeRecommendation(instance, advisorName, rec)
-		err = r.client.Status().Update(context.TODO(), instance)
-	}
-
-	if err != nil {
-		klog.Error("Veto failed to provide recommendation, error: ", err)
-	}
-
-	return reconcile.Result{}, err
+func (r *vetoRecommender) Type() corev1alpha1.AdvisorType {
+	return corev1alpha1.AdvisorTypePredicate
 }
@@ -0,0 +1,65 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package v1alpha1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var (
+	// LabelPlacementRule is the name of the placement rule a PlacementRecommendation is made for,
+	// hashed if it is not a valid label value
+	LabelPlacementRule = SchemeGroupVersion.Group + "/placementrule"
+
+	// LabelAdvisor is the instance id of the advisor making a PlacementRecommendation, hashed if it is not a valid label value
+	LabelAdvisor = SchemeGroupVersion.Group + "/advisor"
+)
+
+// PlacementRecommendationSpec is the recommendation of an advisor for a round of candidates of a placement rule
+type PlacementRecommendationSpec struct {
+	PlacementRef   corev1.LocalObjectReference `json:"placementRef"` // placement rule in the same namespace
+	Advisor        string                      `json:"advisor"`      // instance id of the advisor in the placement rule
+	Generation     int64                       `json:"generation"`   // observed generation of the placement rule
+	Round          int64                       `json:"round"`        // decision round of the placement rule
+	Recommendation Recommendation              `json:"recommendation,omitempty"`
+	Error          string                      `json:"error,omitempty"` // reported instead of a recommendation
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PlacementRecommendation is the Schema for the placementrecommendations API,
+// advisors create one per placement rule instead of writing the rule status
+// +kubebuilder:resource:path=placementrecommendations,scope=Namespaced
+// +kubebuilder:resource:path=placementrecommendations,shortName=hprec
+type PlacementRecommendation struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec PlacementRecommendationSpec `json:"spec,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PlacementRecommendationList contains a list of PlacementRecommendation
+type PlacementRecommendationList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []PlacementRecommendation `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&PlacementRecommendation{}, &PlacementRecommendationList{})
+}
@@ -18,6 +18,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 type AdvisorType string
@@ -43,17 +44,173 @@ const (
 	LocalClusterName = "local-cluster"
 )
 
+// PlacementRule condition types and reasons
+const (
+	// PlacementRuleConditionDegraded is true when candidates could not be generated and the decisions are kept from an earlier reconcile
+	PlacementRuleConditionDegraded = "Degraded"
+	// PlacementRuleConditionReady is true when the decisions of the rule are settled and satisfy its replicas
+	PlacementRuleConditionReady = "Ready"
+	// PlacementRuleConditionAdvisorsPending is true when advisors of the rule have not recommended for the current round
+	PlacementRuleConditionAdvisorsPending = "AdvisorsPending"
+	// PlacementRuleConditionUnsatisfiable is true when fewer targets than the rule replicas pass the predicates
+	PlacementRuleConditionUnsatisfiable = "Unsatisfiable"
+	// PlacementRuleConditionFallbackActive is true when no candidate passes the predicates and the rule is placed on
+	// its fallback targets, only set for rules with a fallback
+	PlacementRuleConditionFallbackActive = "FallbackActive"
+
+	// PlacementRuleReasonDecided means the decisions are settled for the current candidates and recommendations
+	PlacementRuleReasonDecided = "Decided"
+	// PlacementRuleReasonDeciding means candidates are still being eliminated toward the rule replicas
+	PlacementRuleReasonDeciding = "Deciding"
+	// PlacementRuleReasonWaitingForAdvisors means recommendations are missing from at least one advisor
+	PlacementRuleReasonWaitingForAdvisors = "WaitingForAdvisors"
+	// PlacementRuleReasonAdvisorsFailed means predicate advisors of the rule reported errors instead of recommending
+	PlacementRuleReasonAdvisorsFailed = "AdvisorsFailed"
+	// PlacementRuleReasonAdvisorsTimedOut means advisors failing the rule have not recommended before their deadline
+	PlacementRuleReasonAdvisorsTimedOut = "AdvisorsTimedOut"
+	// PlacementRuleReasonAdvisorsRecommended means every advisor of the rule has recommended for the current round
+	PlacementRuleReasonAdvisorsRecommended = "AdvisorsRecommended"
+	// PlacementRuleReasonNoCandidates means no target is left after the predicates
+	PlacementRuleReasonNoCandidates = "NoCandidates"
+	// PlacementRuleReasonInsufficientCandidates means fewer targets than the rule replicas are left after the predicates
+	PlacementRuleReasonInsufficientCandidates = "InsufficientCandidates"
+	// PlacementRuleReasonEnoughCandidates means at least as many targets as the rule replicas are left after the predicates
+	PlacementRuleReasonEnoughCandidates = "EnoughCandidates"
+	// PlacementRuleReasonFallbackTargets means the decisions are the fallback targets of the rule
+	PlacementRuleReasonFallbackTargets = "FallbackTargets"
+	// PlacementRuleReasonNoFallbackTargets means no target matches the fallback of the rule
+	PlacementRuleReasonNoFallbackTargets = "NoFallbackTargets"
+	// PlacementRuleReasonCandidatesDecided means the decisions are made from the candidates, the fallback is not used
+	PlacementRuleReasonCandidatesDecided = "CandidatesDecided"
+
+	// PlacementRuleReasonInvalidAdvisors means advisors of the rule share an instance id
+	PlacementRuleReasonInvalidAdvisors = "InvalidAdvisors"
+	// PlacementRuleReasonUnknownDecisionMaker means the decision maker of the rule is not registered in the operator
+	PlacementRuleReasonUnknownDecisionMaker = "UnknownDecisionMaker"
+
+	// PlacementRuleReasonTargetNotFound means no deployer defines the placement target of the rule deployer type
+	PlacementRuleReasonTargetNotFound = "TargetNotFound"
+	// PlacementRuleReasonCandidatesFailed means the placement targets could not be listed or filtered
+	PlacementRuleReasonCandidatesFailed = "CandidateGenerationFailed"
+	// PlacementRuleReasonCandidatesGenerated means the candidates were generated from the latest spec and targets
+	PlacementRuleReasonCandidatesGenerated = "CandidatesGenerated"
+)
+
 const (
 	DefaultAdvisorWeight  = 100
 	DefaultDecisionWeight = 100
 	DefaultScore          = 100
+	DefaultMinScore       = 0
+	DefaultMaxScore       = DefaultScore
+
+	// ScorePrecision is the number of fractions of a point in the values and totals of candidate scores
+	ScorePrecision = 1000
+)
+
+// ScoreRange is the range of the scores recommended by a priority advisor, scores are normalized over it
+type ScoreRange struct {
+	// +kubebuilder:validation:Minimum=0
+	Min int16 `json:"min"`
+	// +kubebuilder:validation:Minimum=1
+	Max int16 `json:"max"`
+}
+
+// AdvisorFailurePolicy selects how a rule is decided when an advisor has not recommended before its timeout
+// +kubebuilder:validation:Enum=Ignore;Fail;UseLastKnown
+type AdvisorFailurePolicy string
+
+const (
+	// AdvisorFailurePolicyIgnore decides without the advisor for the round, as if it recommended every candidate with its minimum score
+	AdvisorFailurePolicyIgnore AdvisorFailurePolicy = "Ignore"
+	// AdvisorFailurePolicyFail holds the decisions until the advisor recommends
+	AdvisorFailurePolicyFail AdvisorFailurePolicy = "Fail"
+	// AdvisorFailurePolicyUseLastKnown decides with the last recommendation of the advisor in an earlier round,
+	// limited to the current candidates, or ignores the advisor if it never recommended
+	AdvisorFailurePolicyUseLastKnown AdvisorFailurePolicy = "UseLastKnown"
 )
 
 type Advisor struct {
-	Name   string                `json:"name"`
-	Type   *AdvisorType          `json:"type,omitempty"`
-	Weight *int16                `json:"weight,omitempty"`
-	Rules  *runtime.RawExtension `json:"rules,omitempty"`
+	Name          string                `json:"name"`
+	ID            string                `json:"id,omitempty"` // unique in the rule, default: name
+	Type          *AdvisorType          `json:"type,omitempty"`
+	Weight        *int16                `json:"weight,omitempty"`
+	ScoreRange    *ScoreRange           `json:"scoreRange,omitempty"` // nil: 0-100
+	Rules         *runtime.RawExtension `json:"rules,omitempty"`
+	Timeout       *metav1.Duration      `json:"timeout,omitempty"`       // per round, nil: wait indefinitely
+	FailurePolicy *AdvisorFailurePolicy `json:"failurePolicy,omitempty"` // nil: Fail
+	Preferred     bool                  `json:"preferred,omitempty"`     // predicate relaxed, lowest weight first, when too few candidates pass
+}
+
+// InstanceID returns the id of the advisor in the rule, recommendations and advisor states in the rule status are keyed by it.
+// Several instances of an advisor with different ids can advise the same rule.
+func (a *Advisor) InstanceID() string {
+	if a.ID == "" {
+		return a.Name
+	}
+
+	return a.ID
+}
+
+// TieBreakPolicy orders candidates with the same score, the preferred candidate is kept
+// +kubebuilder:validation:Enum=Name;UID;Hash;Age
+type TieBreakPolicy string
+
+const (
+	// TieBreakByName prefers the lowest namespace/name
+	TieBreakByName TieBreakPolicy = "Name"
+	// TieBreakByUID prefers the lowest hash of the target UID
+	TieBreakByUID TieBreakPolicy = "UID"
+	// TieBreakByHash prefers the lowest hash of the rule namespace/name and the target UID, spreading rules over targets
+	TieBreakByHash TieBreakPolicy = "Hash"
+	// TieBreakByAge prefers the oldest target
+	TieBreakByAge TieBreakPolicy = "Age"
+)
+
+// DecisionMode selects how the candidates are reduced to the rule replicas
+// +kubebuilder:validation:Enum=Iterative;SinglePass
+type DecisionMode string
+
+const (
+	// DecisionModeIterative eliminates the lowest scored candidates round by round, advisors recommend again every round
+	DecisionModeIterative DecisionMode = "Iterative"
+	// DecisionModeSinglePass ranks the candidates once on the first recommendations and keeps the top replicas
+	DecisionModeSinglePass DecisionMode = "SinglePass"
+)
+
+// AdvisorPipeline selects which advisors recommend in a decision round
+// +kubebuilder:validation:Enum=Parallel;Staged
+type AdvisorPipeline string
+
+const (
+	// AdvisorPipelineParallel asks every advisor to recommend for all the candidates in every round
+	AdvisorPipelineParallel AdvisorPipeline = "Parallel"
+	// AdvisorPipelineStaged asks the predicate advisors first, the vetoed candidates are eliminated and the priority
+	// advisors only score the predicated candidates, in the next rounds too
+	AdvisorPipelineStaged AdvisorPipeline = "Staged"
+)
+
+// StepPolicy selects how many candidates are eliminated per round in the iterative decision mode
+// +kubebuilder:validation:Enum=Fixed;Adaptive
+type StepPolicy string
+
+const (
+	// StepPolicyFixed eliminates the step size per round, a count or a percentage of the remaining candidates
+	StepPolicyFixed StepPolicy = "Fixed"
+	// StepPolicyAdaptive eliminates half of the candidates in excess of the replicas per round
+	StepPolicyAdaptive StepPolicy = "Adaptive"
+)
+
+// EliminationStep is the number of candidates eliminated per round, never eliminating below the rule replicas
+type EliminationStep struct {
+	Policy StepPolicy          `json:"policy,omitempty"` // default: Fixed
+	Size   *intstr.IntOrString `json:"size,omitempty"`   // Fixed: e.g. 5 or 10%, nil: 1
+}
+
+// Fallback selects the targets the rule is placed on when no candidate passes the predicates, e.g. a disaster recovery site.
+// Fallback targets are filtered like the targets of the rule, up to its replicas.
+type Fallback struct {
+	Targets      []corev1.ObjectReference `json:"targets,omitempty"`      // nil: all
+	TargetLabels *metav1.LabelSelector    `json:"targetLabels,omitempty"` // nil: all
 }
 
 // PlacementRuleSpec defines the desired state of PlacementRule
@@ -64,26 +221,128 @@ type PlacementRuleSpec struct {
 	Targets        []corev1.ObjectReference `json:"targets,omitempty"`        // nil: all
 	TargetLabels   *metav1.LabelSelector    `json:"targetLabels,omitempty"`   // nil: all
 	DecisionWeight *int16                   `json:"decisionWeight,omitempty"` // nil: 100
-	Replicas       *int16                   `json:"replicas,omitempty"`       // nil: all
-	Advisors       []Advisor                `json:"advisors,omitempty"`
+	// +kubebuilder:validation:Minimum=0
+	Replicas      *int16           `json:"replicas,omitempty"`      // nil: all
+	TieBreak      *TieBreakPolicy  `json:"tieBreak,omitempty"`      // nil: Name
+	DecisionMaker *string          `json:"decisionMaker,omitempty"` // nil: default
+	DecisionMode  *DecisionMode    `json:"decisionMode,omitempty"`  // nil: Iterative
+	Step          *EliminationStep `json:"step,omitempty"`          // nil: 1 candidate per round
+	Pipeline      *AdvisorPipeline `json:"pipeline,omitempty"`      // nil: Parallel
+	Fallback      *Fallback        `json:"fallback,omitempty"`      // nil: no decision without candidates
+	Advisors      []Advisor        `json:"advisors,omitempty"`
 }
 
 type ScoredObjectReference struct {
 	corev1.ObjectReference `json:",inline"`
 	// +kubebuilder:validation:Minimum=0
-	// +kubebuilder:validation:Maximum=100
-	Score *int16 `json:"score,omitempty"` // default is 100
+	Score *int16 `json:"score,omitempty"` // default is the max of the advisor score range
 }
 type Recommendation []ScoredObjectReference
 
-// PlacementRuleStatus defines the observed state of PlacementRule
+type TargetFilterType string
+
+const (
+	// TargetFilterTypeIgnored counts targets in IgnoredTargets
+	TargetFilterTypeIgnored TargetFilterType = "ignored"
+	// TargetFilterTypeFiltered counts targets not matching targetLabels, targets or deployerType of the spec
+	TargetFilterTypeFiltered TargetFilterType = "filtered"
+	// TargetFilterTypeVetoed counts candidates not recommended by a predicate advisor
+	TargetFilterTypeVetoed TargetFilterType = "vetoed"
+)
+
+// TargetFilter counts the placement targets excluded by one filter of the rule
+type TargetFilter struct {
+	Type TargetFilterType `json:"type"`
+	Name string           `json:"name"` // spec field or predicate advisor instance id
+	// +kubebuilder:validation:Minimum=0
+	Count int32 `json:"count"`
+	// +kubebuilder:validation:Minimum=0
+	Eliminated int32    `json:"eliminated,omitempty"` // vetoed: candidates eliminated in earlier rounds, included in count
+	Names      []string `json:"names,omitempty"`      // ignored: names of the ignored targets
+}
+
+// TargetSummary explains how many placement targets are eligible for the rule and why the others are not
+type TargetSummary struct {
+	Targets  int32          `json:"targets"`
+	Eligible int32          `json:"eligible"`
+	Filters  []TargetFilter `json:"filters,omitempty"`
+	Message  string         `json:"message,omitempty"` // e.g. 0/12 targets eligible: 7 vetoed by veto, 3 filtered by targetLabels
+}
+
+// AdvisorScore is the contribution of a priority advisor to the score of a candidate
+type AdvisorScore struct {
+	Advisor string `json:"advisor"` // instance id
+	Score   int16  `json:"score"`
+	Weight  int16  `json:"weight"`
+	Value   int64  `json:"value"` // normalized score x weight as added to the total, in 1/ScorePrecision points
+}
+
+// CandidateScore explains the score of a candidate in a decision round, or why it was eliminated
+type CandidateScore struct {
+	corev1.ObjectReference `json:",inline"`
+	Round                  int64          `json:"round"`
+	VetoedBy               string         `json:"vetoedBy,omitempty"` // instance id of the predicate advisor eliminating the candidate
+	Advisors               []AdvisorScore `json:"advisors,omitempty"`
+	Decision               int64          `json:"decision,omitempty"` // decisionWeight bonus of an earlier decision, in 1/ScorePrecision points
+	Total                  int64          `json:"total"`              // in 1/ScorePrecision points
+}
+
+// AdvisorState is the state of an advisor in the current decision round
+type AdvisorState string
+
+const (
+	// AdvisorStatePending means the advisor has not recommended for the current round
+	AdvisorStatePending AdvisorState = "Pending"
+	// AdvisorStateRecommended means the advisor has recommended for the current round
+	AdvisorStateRecommended AdvisorState = "Recommended"
+	// AdvisorStateTimedOut means the advisor has not recommended before its deadline, its failure policy applies
+	AdvisorStateTimedOut AdvisorState = "TimedOut"
+	// AdvisorStateFailed means the advisor reported an error instead of recommending, predicate advisors hold the decisions
+	// and priority advisors are ignored for the round
+	AdvisorStateFailed AdvisorState = "Failed"
+	// AdvisorStateIdle means the advisor is not asked to recommend in the current stage of the round
+	AdvisorStateIdle AdvisorState = "Idle"
+)
+
+// AdvisorError is an error reported by an advisor failing to recommend in a decision round
+type AdvisorError struct {
+	Round   int64  `json:"round"`
+	Message string `json:"message"`
+}
+
+// AdvisorStatus is the state of an advisor of the rule in the current decision round
+type AdvisorStatus struct {
+	Name          string               `json:"name"`
+	ID            string               `json:"id"`
+	State         AdvisorState         `json:"state"`
+	Deadline      *metav1.Time         `json:"deadline,omitempty"`      // end of the advisor timeout in the current round
+	FailurePolicy AdvisorFailurePolicy `json:"failurePolicy,omitempty"` // timed out or failed: policy applied
+	Error         string               `json:"error,omitempty"`         // failed: error reported by the advisor
+}
+
+// PlacementRuleStatus defines the observed state of PlacementRule.
+// Recommendations, RecommendationRounds and AdvisorErrors are written by advisors not built on the advisor framework,
+// framework advisors write PlacementRecommendations instead.
 type PlacementRuleStatus struct {
-	ObservedGeneration int64                     `json:"observedGeneration,omitempty"`
-	LastUpdateTime     *metav1.Time              `json:"lastUpdateTime,omitempty"`
-	Candidates         []corev1.ObjectReference  `json:"candidates,omitempty"`
-	Eliminators        []corev1.ObjectReference  `json:"eliminators,omitempty"`
-	Recommendations    map[string]Recommendation `json:"recommendations,omitempty"` // key: advisor name
-	Decisions          []corev1.ObjectReference  `json:"decisions,omitempty"`
+	ObservedGeneration       int64                     `json:"observedGeneration,omitempty"`
+	LastUpdateTime           *metav1.Time              `json:"lastUpdateTime,omitempty"`
+	Candidates               []corev1.ObjectReference  `json:"candidates,omitempty"`
+	Eliminators              []corev1.ObjectReference  `json:"eliminators,omitempty"`
+	Round                    int64                     `json:"round,omitempty"`                    // current decision round
+	RoundStartTime           *metav1.Time              `json:"roundStartTime,omitempty"`           // advisor deadlines start from it
+	Stage                    AdvisorType               `json:"stage,omitempty"`                    // type of the advisors asked in the current round, empty: all
+	Scores                   []CandidateScore          `json:"scores,omitempty"`                   // candidates scored in the last round
+	Eliminations             []CandidateScore          `json:"eliminations,omitempty"`             // eliminators with the round and scores they were eliminated by
+	Recommendations          map[string]Recommendation `json:"recommendations,omitempty"`          // key: advisor instance id
+	RecommendationRounds     map[string]int64          `json:"recommendationRounds,omitempty"`     // key: advisor instance id, round recommended for
+	LastKnownRecommendations map[string]Recommendation `json:"lastKnownRecommendations,omitempty"` // key: advisor instance id, from earlier rounds
+	AdvisorErrors            map[string]AdvisorError   `json:"advisorErrors,omitempty"`            // key: advisor instance id
+	Advisors                 []AdvisorStatus           `json:"advisors,omitempty"`
+	RelaxedAdvisors          []string                  `json:"relaxedAdvisors,omitempty"` // instance ids of the preferred predicates relaxed, in order
+	Decisions                []corev1.ObjectReference  `json:"decisions,omitempty"`
+	FallbackTargets          []corev1.ObjectReference  `json:"fallbackTargets,omitempty"` // targets matching the fallback, by namespace/name
+	TargetSummary            *TargetSummary            `json:"targetSummary,omitempty"`
+	Conditions               []metav1.Condition        `json:"conditions,omitempty"`
 }
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
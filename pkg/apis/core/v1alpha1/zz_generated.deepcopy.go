@@ -23,6 +23,7 @@ import (
 	rbacv1 "k8s.io/api/rbac/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	intstr "k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -38,11 +39,26 @@ func (in *Advisor) DeepCopyInto(out *Advisor) {
 		*out = new(int16)
 		**out = **in
 	}
+	if in.ScoreRange != nil {
+		in, out := &in.ScoreRange, &out.ScoreRange
+		*out = new(ScoreRange)
+		**out = **in
+	}
 	if in.Rules != nil {
 		in, out := &in.Rules, &out.Rules
 		*out = new(runtime.RawExtension)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Timeout != nil {
+		in, out := &in.Timeout, &out.Timeout
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.FailurePolicy != nil {
+		in, out := &in.FailurePolicy, &out.FailurePolicy
+		*out = new(AdvisorFailurePolicy)
+		**out = **in
+	}
 	return
 }
 
@@ -56,6 +72,80 @@ func (in *Advisor) DeepCopy() *Advisor {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdvisorError) DeepCopyInto(out *AdvisorError) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdvisorError.
+func (in *AdvisorError) DeepCopy() *AdvisorError {
+	if in == nil {
+		return nil
+	}
+	out := new(AdvisorError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdvisorScore) DeepCopyInto(out *AdvisorScore) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdvisorScore.
+func (in *AdvisorScore) DeepCopy() *AdvisorScore {
+	if in == nil {
+		return nil
+	}
+	out := new(AdvisorScore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AdvisorStatus) DeepCopyInto(out *AdvisorStatus) {
+	*out = *in
+	if in.Deadline != nil {
+		in, out := &in.Deadline, &out.Deadline
+		*out = (*in).DeepCopy()
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AdvisorStatus.
+func (in *AdvisorStatus) DeepCopy() *AdvisorStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(AdvisorStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CandidateScore) DeepCopyInto(out *CandidateScore) {
+	*out = *in
+	out.ObjectReference = in.ObjectReference
+	if in.Advisors != nil {
+		in, out := &in.Advisors, &out.Advisors
+		*out = make([]AdvisorScore, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CandidateScore.
+func (in *CandidateScore) DeepCopy() *CandidateScore {
+	if in == nil {
+		return nil
+	}
+	out := new(CandidateScore)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Deployer) DeepCopyInto(out *Deployer) {
 	*out = *in
@@ -305,6 +395,137 @@ func (in *DeployerStatusDescriptor) DeepCopy() *DeployerStatusDescriptor {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EliminationStep) DeepCopyInto(out *EliminationStep) {
+	*out = *in
+	if in.Size != nil {
+		in, out := &in.Size, &out.Size
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EliminationStep.
+func (in *EliminationStep) DeepCopy() *EliminationStep {
+	if in == nil {
+		return nil
+	}
+	out := new(EliminationStep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Fallback) DeepCopyInto(out *Fallback) {
+	*out = *in
+	if in.Targets != nil {
+		in, out := &in.Targets, &out.Targets
+		*out = make([]corev1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.TargetLabels != nil {
+		in, out := &in.TargetLabels, &out.TargetLabels
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Fallback.
+func (in *Fallback) DeepCopy() *Fallback {
+	if in == nil {
+		return nil
+	}
+	out := new(Fallback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementRecommendation) DeepCopyInto(out *PlacementRecommendation) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementRecommendation.
+func (in *PlacementRecommendation) DeepCopy() *PlacementRecommendation {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementRecommendation)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlacementRecommendation) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementRecommendationList) DeepCopyInto(out *PlacementRecommendationList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]PlacementRecommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementRecommendationList.
+func (in *PlacementRecommendationList) DeepCopy() *PlacementRecommendationList {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementRecommendationList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlacementRecommendationList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlacementRecommendationSpec) DeepCopyInto(out *PlacementRecommendationSpec) {
+	*out = *in
+	out.PlacementRef = in.PlacementRef
+	if in.Recommendation != nil {
+		in, out := &in.Recommendation, &out.Recommendation
+		*out = make(Recommendation, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlacementRecommendationSpec.
+func (in *PlacementRecommendationSpec) DeepCopy() *PlacementRecommendationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PlacementRecommendationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlacementRule) DeepCopyInto(out *PlacementRule) {
 	*out = *in
@@ -394,6 +615,36 @@ func (in *PlacementRuleSpec) DeepCopyInto(out *PlacementRuleSpec) {
 		*out = new(int16)
 		**out = **in
 	}
+	if in.TieBreak != nil {
+		in, out := &in.TieBreak, &out.TieBreak
+		*out = new(TieBreakPolicy)
+		**out = **in
+	}
+	if in.DecisionMaker != nil {
+		in, out := &in.DecisionMaker, &out.DecisionMaker
+		*out = new(string)
+		**out = **in
+	}
+	if in.DecisionMode != nil {
+		in, out := &in.DecisionMode, &out.DecisionMode
+		*out = new(DecisionMode)
+		**out = **in
+	}
+	if in.Step != nil {
+		in, out := &in.Step, &out.Step
+		*out = new(EliminationStep)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Pipeline != nil {
+		in, out := &in.Pipeline, &out.Pipeline
+		*out = new(AdvisorPipeline)
+		**out = **in
+	}
+	if in.Fallback != nil {
+		in, out := &in.Fallback, &out.Fallback
+		*out = new(Fallback)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.Advisors != nil {
 		in, out := &in.Advisors, &out.Advisors
 		*out = make([]Advisor, len(*in))
@@ -431,6 +682,24 @@ func (in *PlacementRuleStatus) DeepCopyInto(out *PlacementRuleStatus) {
 		*out = make([]corev1.ObjectReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.RoundStartTime != nil {
+		in, out := &in.RoundStartTime, &out.RoundStartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Scores != nil {
+		in, out := &in.Scores, &out.Scores
+		*out = make([]CandidateScore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Eliminations != nil {
+		in, out := &in.Eliminations, &out.Eliminations
+		*out = make([]CandidateScore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	if in.Recommendations != nil {
 		in, out := &in.Recommendations, &out.Recommendations
 		*out = make(map[string]Recommendation, len(*in))
@@ -449,11 +718,72 @@ func (in *PlacementRuleStatus) DeepCopyInto(out *PlacementRuleStatus) {
 			(*out)[key] = outVal
 		}
 	}
+	if in.RecommendationRounds != nil {
+		in, out := &in.RecommendationRounds, &out.RecommendationRounds
+		*out = make(map[string]int64, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LastKnownRecommendations != nil {
+		in, out := &in.LastKnownRecommendations, &out.LastKnownRecommendations
+		*out = make(map[string]Recommendation, len(*in))
+		for key, val := range *in {
+			var outVal []ScoredObjectReference
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				value := val
+				in, out := &value, &outVal
+				*out = make(Recommendation, len(*in))
+				for i := range *in {
+					(*in)[i].DeepCopyInto(&(*out)[i])
+				}
+			}
+			(*out)[key] = outVal
+		}
+	}
+	if in.AdvisorErrors != nil {
+		in, out := &in.AdvisorErrors, &out.AdvisorErrors
+		*out = make(map[string]AdvisorError, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Advisors != nil {
+		in, out := &in.Advisors, &out.Advisors
+		*out = make([]AdvisorStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.RelaxedAdvisors != nil {
+		in, out := &in.RelaxedAdvisors, &out.RelaxedAdvisors
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.Decisions != nil {
 		in, out := &in.Decisions, &out.Decisions
 		*out = make([]corev1.ObjectReference, len(*in))
 		copy(*out, *in)
 	}
+	if in.FallbackTargets != nil {
+		in, out := &in.FallbackTargets, &out.FallbackTargets
+		*out = make([]corev1.ObjectReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.TargetSummary != nil {
+		in, out := &in.TargetSummary, &out.TargetSummary
+		*out = new(TargetSummary)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -489,6 +819,22 @@ func (in Recommendation) DeepCopy() Recommendation {
 	return *out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ScoreRange) DeepCopyInto(out *ScoreRange) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ScoreRange.
+func (in *ScoreRange) DeepCopy() *ScoreRange {
+	if in == nil {
+		return nil
+	}
+	out := new(ScoreRange)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ScoredObjectReference) DeepCopyInto(out *ScoredObjectReference) {
 	*out = *in
@@ -510,3 +856,47 @@ func (in *ScoredObjectReference) DeepCopy() *ScoredObjectReference {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetFilter) DeepCopyInto(out *TargetFilter) {
+	*out = *in
+	if in.Names != nil {
+		in, out := &in.Names, &out.Names
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetFilter.
+func (in *TargetFilter) DeepCopy() *TargetFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TargetSummary) DeepCopyInto(out *TargetSummary) {
+	*out = *in
+	if in.Filters != nil {
+		in, out := &in.Filters, &out.Filters
+		*out = make([]TargetFilter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TargetSummary.
+func (in *TargetSummary) DeepCopy() *TargetSummary {
+	if in == nil {
+		return nil
+	}
+	out := new(TargetSummary)
+	in.DeepCopyInto(out)
+	return out
+}
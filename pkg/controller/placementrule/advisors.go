@@ -0,0 +1,44 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"fmt"
+	"strings"
+
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// validateAdvisors returns an error if advisors of the rule share an instance id, their recommendations would overwrite each other.
+// Instance ids differing only in case are shared, like advisor names matching advisors.
+func validateAdvisors(instance *corev1alpha1.PlacementRule) error {
+	ids := make(map[string]bool)
+
+	for _, adv := range instance.Spec.Advisors {
+		id := adv.InstanceID()
+		if id == "" {
+			return fmt.Errorf("advisor without name or id in placement rule %s/%s", instance.Namespace, instance.Name)
+		}
+
+		if ids[strings.ToLower(id)] {
+			return fmt.Errorf("advisor instance id %s is used more than once in placement rule %s/%s, set a unique id for each instance",
+				id, instance.Namespace, instance.Name)
+		}
+
+		ids[strings.ToLower(id)] = true
+	}
+
+	return nil
+}
@@ -15,18 +15,26 @@ package placementrule
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"reflect"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/klog"
 
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
 	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
 )
 
+// errTargetNotFound is returned when no deployer defines the placement target of the rule deployer type
+var errTargetNotFound = errors.New("no deployer found for deployer type")
+
 func convertMetaGVRToScheme(mgvr *metav1.GroupVersionResource) *schema.GroupVersionResource {
 	if mgvr == nil {
 		return nil
@@ -39,8 +47,8 @@ func convertMetaGVRToScheme(mgvr *metav1.GroupVersionResource) *schema.GroupVers
 	}
 }
 
-// check deployer by type everytime. could cache it.
-func (r *ReconcilePlacementRule) getTargetGVR(instance *corev1alpha1.PlacementRule) (*schema.GroupVersionResource, error) {
+// getTargetGVR looks up the deployer of the rule deployer type through the deployer type index of the cache
+func getTargetGVR(rdr client.Reader, instance *corev1alpha1.PlacementRule) (*schema.GroupVersionResource, error) {
 	dplylist := &corev1alpha1.DeployerList{}
 
 	// do not check the default deployertyp here in case user wants to override target for default deployer type
@@ -50,9 +58,9 @@ func (r *ReconcilePlacementRule) getTargetGVR(instance *corev1alpha1.PlacementRu
 
 	dplytype := *instance.Spec.DeployerType
 
-	err := r.client.List(context.TODO(), dplylist)
+	err := rdr.List(context.TODO(), dplylist, client.MatchingFields{deployerTypeIndex: dplytype})
 	if err != nil {
-		klog.Error("Failed to list deployers in system with error: ", err)
+		klog.Error("Failed to list deployers of type ", dplytype, " with error: ", err)
 		return nil, err
 	}
 
@@ -69,117 +77,169 @@ func (r *ReconcilePlacementRule) getTargetGVR(instance *corev1alpha1.PlacementRu
 	return nil, nil
 }
 
-func (r *ReconcilePlacementRule) generateCandidates(instance *corev1alpha1.PlacementRule) ([]corev1.ObjectReference, error) {
+// generateCandidates returns the candidates of the rule, and the summary of the targets filtered by the spec
+func (r *ReconcilePlacementRule) generateCandidates(instance *corev1alpha1.PlacementRule) ([]corev1.ObjectReference,
+	*corev1alpha1.TargetSummary, error) {
 	if instance == nil {
-		return nil, nil
+		return nil, nil, nil
 	}
 
 	var candidates []corev1.ObjectReference
 
-	// select by targetLabels, nil = everything
-	listopts := metav1.ListOptions{}
-
+	// validate targetLabels, targets filtered by labels are counted in the summary
 	if instance.Spec.TargetLabels != nil {
-		selector, err := metav1.LabelSelectorAsSelector(instance.Spec.TargetLabels)
-		if err != nil {
+		if _, err := metav1.LabelSelectorAsSelector(instance.Spec.TargetLabels); err != nil {
 			klog.Error("Failed to parse label selector with error: ", err)
-			return nil, err
+			return nil, nil, err
 		}
-
-		listopts.LabelSelector = selector.String()
 	}
 
-	gvr, err := r.getTargetGVR(instance)
+	gvr, err := getTargetGVR(r.client, instance)
 	if err != nil {
 		klog.Error("Failed to get target GroupVersionResource for placement rule with error: ", err)
-		return nil, err
+		return nil, nil, err
 	}
 	if gvr == nil {
 		klog.Error("No target GroupVersionResource could be found for placement rule ", instance.Namespace+"/"+instance.Name,
 			". If deployerType is defined in the placement rule , a matching explicit deployer needs to exist on the platform.")
 
-		return nil, err
+		return nil, nil, fmt.Errorf("%w %s", errTargetNotFound, *instance.Spec.DeployerType)
 	}
 
-	tl, err := r.dynamicClient.Resource(*gvr).List(context.TODO(), listopts)
+	tl, err := r.targets.list(*gvr, labels.Everything())
 	if err != nil {
 		klog.Error("Failed to list ", gvr.String(), " with error: ", err)
-		return nil, err
+		return nil, nil, err
 	}
 
+	summary := &corev1alpha1.TargetSummary{Targets: int32(len(tl.Items))}
+
 	// build candidate list, filter targets, nil = everything
-	for _, obj := range tl.Items {
-		or := corev1.ObjectReference{
-			Kind:       obj.GroupVersionKind().Kind,
-			Name:       obj.GetName(),
-			Namespace:  obj.GetNamespace(),
-			APIVersion: obj.GetAPIVersion(),
-			UID:        obj.GetUID(),
-		}
-		pass := true
-
-		// check ignored targets
-		for _, ignoredTarget := range corev1alpha1.IgnoredTargets {
-			if or.Kind == ignoredTarget.Kind && or.APIVersion == ignoredTarget.APIVersion &&
-				or.Name == ignoredTarget.Name && or.Namespace == ignoredTarget.Namespace {
-				pass = false
-				break
-			}
+	for i := range tl.Items {
+		obj := &tl.Items[i]
+
+		filter, err := filterTarget(instance, gvr, obj, r.ignoredTargets)
+		if err != nil {
+			return nil, nil, err
 		}
 
-		if !pass {
+		if filter == "" {
+			candidates = append(candidates, objectReference(obj))
 			continue
 		}
 
-		// check targets
-		if len(instance.Spec.Targets) > 0 {
-			pass = false
+		addTargetFilter(summary, filter, obj.GetName())
+	}
+
+	return candidates, summary, nil
+}
+
+func objectReference(obj *unstructured.Unstructured) corev1.ObjectReference {
+	return corev1.ObjectReference{
+		Kind:       obj.GroupVersionKind().Kind,
+		Name:       obj.GetName(),
+		Namespace:  obj.GetNamespace(),
+		APIVersion: obj.GetAPIVersion(),
+		UID:        obj.GetUID(),
+	}
+}
+
+// isCandidate checks a target object of gvr against ignored targets, targets, targetLabels and deployerType of the rule
+func isCandidate(instance *corev1alpha1.PlacementRule, gvr *schema.GroupVersionResource, obj *unstructured.Unstructured,
+	ignoredTargets []corev1.ObjectReference) (bool, error) {
+	filter, err := filterTarget(instance, gvr, obj, ignoredTargets)
+
+	return filter == "" && err == nil, err
+}
+
+// filterTarget returns the filter of the rule excluding a target object of gvr, empty if the target is a candidate
+func filterTarget(instance *corev1alpha1.PlacementRule, gvr *schema.GroupVersionResource, obj *unstructured.Unstructured,
+	ignoredTargets []corev1.ObjectReference) (string, error) {
+	or := objectReference(obj)
+
+	// check ignored targets
+	for _, ignoredTarget := range ignoredTargets {
+		if or.Kind == ignoredTarget.Kind && or.APIVersion == ignoredTarget.APIVersion &&
+			or.Name == ignoredTarget.Name && or.Namespace == ignoredTarget.Namespace {
+			return filterIgnoredTargets, nil
 		}
+	}
 
-		for _, t := range instance.Spec.Targets {
-			if t.Name != "" && t.Name != or.Name {
-				continue
-			}
+	// check targetLabels, nil = everything
+	if instance.Spec.TargetLabels != nil {
+		selector, err := metav1.LabelSelectorAsSelector(instance.Spec.TargetLabels)
+		if err != nil {
+			klog.Error("Failed to parse label selector with error: ", err)
+			return "", err
+		}
 
-			if t.Namespace != "" && t.Namespace != or.Namespace {
-				continue
-			}
+		if !selector.Matches(labels.Set(obj.GetLabels())) {
+			return filterTargetLabels, nil
+		}
+	}
 
-			pass = true
+	// check targets
+	pass := true
+	if len(instance.Spec.Targets) > 0 {
+		pass = false
+	}
 
-			break
+	for _, t := range instance.Spec.Targets {
+		if t.Name != "" && t.Name != or.Name {
+			continue
 		}
 
-		// validate the deployer type
-		if instance.Spec.DeployerType != nil && reflect.DeepEqual(gvr, convertMetaGVRToScheme(corev1alpha1.DeployerPlacementTarget)) {
+		if t.Namespace != "" && t.Namespace != or.Namespace {
+			continue
+		}
 
-			// retrieve the deployerType
-			deployerType, _, err := unstructured.NestedString(obj.Object, "spec", "type")
-			if err != nil {
-				klog.Error("Failed to retrieve deployer type for ", obj.GetNamespace()+"/"+obj.GetName())
-				return nil, err
-			}
-			if deployerType != *instance.Spec.DeployerType {
-				pass = false
-			}
+		pass = true
 
+		break
+	}
+
+	if !pass {
+		return filterTargets, nil
+	}
+
+	// validate the deployer type
+	if instance.Spec.DeployerType != nil && reflect.DeepEqual(gvr, convertMetaGVRToScheme(corev1alpha1.DeployerPlacementTarget)) {
+		// retrieve the deployerType
+		deployerType, _, err := unstructured.NestedString(obj.Object, "spec", "type")
+		if err != nil {
+			klog.Error("Failed to retrieve deployer type for ", obj.GetNamespace()+"/"+obj.GetName())
+			return "", err
 		}
 
-		if pass {
-			candidates = append(candidates, or)
+		if deployerType != *instance.Spec.DeployerType {
+			return filterDeployerType, nil
 		}
 	}
 
-	return candidates, nil
+	return "", nil
 }
 
-func isSameCandidateList(candidates []corev1.ObjectReference, instance *corev1alpha1.PlacementRule) bool {
-	if candidates == nil && instance == nil {
-		return true
+// isKnownCandidate checks whether the target is already part of the decision making process of the rule
+func isKnownCandidate(instance *corev1alpha1.PlacementRule, uid types.UID) bool {
+	for _, or := range instance.Status.Candidates {
+		if or.UID == uid {
+			return true
+		}
 	}
 
-	if candidates == nil || instance == nil {
-		return false
+	for _, or := range instance.Status.Eliminators {
+		if or.UID == uid {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isSameCandidateList(candidates []corev1.ObjectReference, instance *corev1alpha1.PlacementRule) bool {
+	// no candidates left is the same as no candidates recorded, so that existing decisions can be cleaned up
+	if instance == nil {
+		return candidates == nil
 	}
 
 	newmap := make(map[types.UID]bool)
@@ -0,0 +1,213 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// setCondition sets a status condition of the rule for its current generation, returns true if the condition is changed
+func setCondition(instance *corev1alpha1.PlacementRule, ctype string, status metav1.ConditionStatus, reason, message string) bool {
+	cond := metav1.Condition{
+		Type:               ctype,
+		Status:             status,
+		ObservedGeneration: instance.GetGeneration(),
+		Reason:             reason,
+		Message:            message,
+	}
+
+	old := meta.FindStatusCondition(instance.Status.Conditions, ctype)
+	if old != nil && old.Status == cond.Status && old.ObservedGeneration == cond.ObservedGeneration &&
+		old.Reason == cond.Reason && old.Message == cond.Message {
+		return false
+	}
+
+	meta.SetStatusCondition(&instance.Status.Conditions, cond)
+
+	return true
+}
+
+// setDegraded sets the Degraded condition from the candidate generation error, nil clears it
+func setDegraded(instance *corev1alpha1.PlacementRule, err error) bool {
+	if err == nil {
+		return setCondition(instance, corev1alpha1.PlacementRuleConditionDegraded, metav1.ConditionFalse,
+			corev1alpha1.PlacementRuleReasonCandidatesGenerated, "Candidates are generated from the placement targets")
+	}
+
+	reason := corev1alpha1.PlacementRuleReasonCandidatesFailed
+	if errors.Is(err, errTargetNotFound) {
+		reason = corev1alpha1.PlacementRuleReasonTargetNotFound
+	}
+
+	message := "Decisions are kept from the last successful reconcile: " + err.Error()
+
+	changed := setCondition(instance, corev1alpha1.PlacementRuleConditionDegraded, metav1.ConditionTrue, reason, message)
+
+	return setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse, reason, message) || changed
+}
+
+// pendingAdvisors returns the advisors of the current stage of the rule without recommendation in the current round
+func pendingAdvisors(instance *corev1alpha1.PlacementRule) []string {
+	var pending []string
+
+	for _, adv := range instance.Spec.Advisors {
+		if !advisorutils.InStage(instance, &adv) {
+			continue
+		}
+
+		if _, ok := instance.Status.Recommendations[adv.InstanceID()]; !ok {
+			pending = append(pending, adv.InstanceID())
+		}
+	}
+
+	return pending
+}
+
+// setAdvisorsPending sets the AdvisorsPending condition from the recommendations of the current round,
+// the rule is not ready while advisors are pending
+func setAdvisorsPending(instance *corev1alpha1.PlacementRule, now time.Time) bool {
+	pending := pendingAdvisors(instance)
+	if len(pending) == 0 {
+		return setCondition(instance, corev1alpha1.PlacementRuleConditionAdvisorsPending, metav1.ConditionFalse,
+			corev1alpha1.PlacementRuleReasonAdvisorsRecommended, "All advisors recommended for the current candidates")
+	}
+
+	message := "Waiting for recommendations from advisors: " + strings.Join(pending, ", ")
+
+	changed := setCondition(instance, corev1alpha1.PlacementRuleConditionAdvisorsPending, metav1.ConditionTrue,
+		corev1alpha1.PlacementRuleReasonWaitingForAdvisors, message)
+
+	if errored := erroredAdvisors(instance); len(errored) > 0 {
+		return setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse,
+			corev1alpha1.PlacementRuleReasonAdvisorsFailed,
+			"Decisions are held until failed predicate advisors recommend: "+strings.Join(errored, ", ")) || changed
+	}
+
+	if failed := failedAdvisors(instance, now); len(failed) > 0 {
+		return setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse,
+			corev1alpha1.PlacementRuleReasonAdvisorsTimedOut,
+			"Decisions are held until timed out advisors recommend: "+strings.Join(failed, ", ")) || changed
+	}
+
+	return setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse,
+		corev1alpha1.PlacementRuleReasonWaitingForAdvisors, message) || changed
+}
+
+// setDeciding marks the rule not ready while candidates are eliminated toward its replicas
+func setDeciding(instance *corev1alpha1.PlacementRule, replicas int) bool {
+	message := fmt.Sprintf("%d candidates left for %d replicas", len(instance.Status.Candidates), replicas)
+
+	changed := setCondition(instance, corev1alpha1.PlacementRuleConditionUnsatisfiable, metav1.ConditionFalse,
+		corev1alpha1.PlacementRuleReasonEnoughCandidates, message)
+
+	return setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse,
+		corev1alpha1.PlacementRuleReasonDeciding, message) || changed
+}
+
+// setDecided sets the Ready and Unsatisfiable conditions for settled decisions, nil replicas requests every target
+func setDecided(instance *corev1alpha1.PlacementRule) bool {
+	decisions := len(instance.Status.Decisions)
+
+	replicas := decisions
+	if instance.Spec.Replicas != nil {
+		replicas = int(*instance.Spec.Replicas)
+	}
+
+	changed := setFallbackInactive(instance)
+
+	switch {
+	case decisions == 0 && (instance.Spec.Replicas == nil || replicas > 0):
+		changed = setCondition(instance, corev1alpha1.PlacementRuleConditionUnsatisfiable, metav1.ConditionTrue,
+			corev1alpha1.PlacementRuleReasonNoCandidates, withTargetSummary(instance, "No target is left after the predicates")) || changed
+	case decisions < replicas:
+		changed = setCondition(instance, corev1alpha1.PlacementRuleConditionUnsatisfiable, metav1.ConditionTrue,
+			corev1alpha1.PlacementRuleReasonInsufficientCandidates,
+			withTargetSummary(instance, fmt.Sprintf("%d of %d replicas could be placed", decisions, replicas))) || changed
+	default:
+		changed = setCondition(instance, corev1alpha1.PlacementRuleConditionUnsatisfiable, metav1.ConditionFalse,
+			corev1alpha1.PlacementRuleReasonEnoughCandidates, fmt.Sprintf("%d of %d replicas are placed", decisions, replicas)) || changed
+
+		return setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionTrue,
+			corev1alpha1.PlacementRuleReasonDecided, fmt.Sprintf("%d replicas are placed", decisions)) || changed
+	}
+
+	cond := meta.FindStatusCondition(instance.Status.Conditions, corev1alpha1.PlacementRuleConditionUnsatisfiable)
+
+	return setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse, cond.Reason, cond.Message) || changed
+}
+
+// setFallbackDecided sets the conditions of a rule with a fallback and no candidate left after the predicates,
+// the rule is ready once placed on its fallback targets
+func setFallbackDecided(instance *corev1alpha1.PlacementRule) bool {
+	if instance.Spec.Replicas != nil && *instance.Spec.Replicas == 0 {
+		return setDecided(instance)
+	}
+
+	noCandidates := withTargetSummary(instance, "No target is left after the predicates")
+
+	changed := setCondition(instance, corev1alpha1.PlacementRuleConditionUnsatisfiable, metav1.ConditionTrue,
+		corev1alpha1.PlacementRuleReasonNoCandidates, noCandidates)
+
+	decisions := len(instance.Status.Decisions)
+	if decisions == 0 {
+		changed = setCondition(instance, corev1alpha1.PlacementRuleConditionFallbackActive, metav1.ConditionFalse,
+			corev1alpha1.PlacementRuleReasonNoFallbackTargets, "No target matches the fallback of the rule") || changed
+
+		return setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse,
+			corev1alpha1.PlacementRuleReasonNoCandidates, noCandidates) || changed
+	}
+
+	message := fmt.Sprintf("%d replicas are placed on fallback targets", decisions)
+
+	changed = setCondition(instance, corev1alpha1.PlacementRuleConditionFallbackActive, metav1.ConditionTrue,
+		corev1alpha1.PlacementRuleReasonFallbackTargets, message) || changed
+
+	return setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionTrue,
+		corev1alpha1.PlacementRuleReasonFallbackTargets, message) || changed
+}
+
+// setFallbackInactive sets the FallbackActive condition of a rule decided on its candidates, rules without fallback have none
+func setFallbackInactive(instance *corev1alpha1.PlacementRule) bool {
+	if instance.Spec.Fallback == nil {
+		if meta.FindStatusCondition(instance.Status.Conditions, corev1alpha1.PlacementRuleConditionFallbackActive) == nil {
+			return false
+		}
+
+		meta.RemoveStatusCondition(&instance.Status.Conditions, corev1alpha1.PlacementRuleConditionFallbackActive)
+
+		return true
+	}
+
+	return setCondition(instance, corev1alpha1.PlacementRuleConditionFallbackActive, metav1.ConditionFalse,
+		corev1alpha1.PlacementRuleReasonCandidatesDecided, "Decisions are made from the candidates")
+}
+
+// withTargetSummary appends the target summary of the rule to a condition message
+func withTargetSummary(instance *corev1alpha1.PlacementRule, message string) string {
+	if instance.Status.TargetSummary == nil {
+		return message
+	}
+
+	return message + ", " + instance.Status.TargetSummary.Message
+}
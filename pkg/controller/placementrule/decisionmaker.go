@@ -15,8 +15,13 @@
 package placementrule
 
 import (
+	"math"
+	"reflect"
+	"sort"
+
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/klog"
 
 	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
@@ -33,30 +38,85 @@ type DecisionMaker interface {
 }
 
 type DefaultDecisionMaker struct {
+	// targets is used to tie-break by target age, optional
+	targets *targetCache
 }
 
 func (d *DefaultDecisionMaker) ResetDecisionMakingProcess(candidates []corev1.ObjectReference, instance *corev1alpha1.PlacementRule) {
 	instance.Status.Candidates = candidates
 	instance.Status.Eliminators = nil
+	instance.Status.Eliminations = nil
+	instance.Status.Scores = nil
 	instance.Status.Recommendations = nil
+	instance.Status.RecommendationRounds = nil
+	instance.Status.AdvisorErrors = nil
+	instance.Status.RelaxedAdvisors = nil
+	instance.Status.Stage = firstStage(instance)
+}
+
+// firstStage returns the stage starting the decision making process: staged rules with predicate and priority advisors
+// ask the predicate advisors first, other rules ask every advisor in every round
+func firstStage(instance *corev1alpha1.PlacementRule) corev1alpha1.AdvisorType {
+	if instance.Spec.Pipeline == nil || *instance.Spec.Pipeline != corev1alpha1.AdvisorPipelineStaged {
+		return corev1alpha1.AdvisorTypeUnknown
+	}
+
+	var predicates, priorities bool
+
+	for _, adv := range instance.Spec.Advisors {
+		if adv.Type != nil && *adv.Type == corev1alpha1.AdvisorTypePredicate {
+			predicates = true
+		} else {
+			priorities = true
+		}
+	}
+
+	if predicates && priorities {
+		return corev1alpha1.AdvisorTypePredicate
+	}
+
+	return corev1alpha1.AdvisorTypeUnknown
 }
 
 func (d *DefaultDecisionMaker) ContinueDecisionMakingProcess(instance *corev1alpha1.PlacementRule) bool {
-	decisions := d.filterByAdvisorType(instance.Status.Candidates, instance.Spec.Advisors, instance.Status.Recommendations, corev1alpha1.AdvisorTypePredicate)
+	changed := false
+
+	// preferred predicates are relaxed on the recommendations of the predicate stage
+	if instance.Status.Stage != corev1alpha1.AdvisorTypePriority {
+		changed = setRelaxedAdvisors(instance, d.relaxPredicates(instance))
+		changed = setVetoes(instance, d.countVetoes(instance.Status.Candidates, unrelaxedAdvisors(instance), instance.Status.Recommendations)) || changed
+	}
+
+	decisions := d.predicated(instance)
+
+	predicated := decisions
 
 	if len(decisions) == 0 {
-		if len(instance.Status.Decisions) > 0 {
-			instance.Status.Decisions = nil
-			return true
+		// rules with a fallback are placed on their fallback targets instead of nowhere
+		changed = d.checkAndSetDecisions(fallbackDecisions(instance), instance) || changed
+		changed = d.recordScores(instance, predicated) || changed
+
+		if instance.Spec.Fallback != nil {
+			return setFallbackDecided(instance) || changed
 		}
 
-		return false
+		return setDecided(instance) || changed
 	}
 
 	replicas := len(decisions)
 	if instance.Spec.Replicas != nil {
 		replicas = int(*instance.Spec.Replicas)
 	}
+	// priority advisors score the predicated candidates in the next round
+	if len(decisions) > replicas && instance.Status.Stage == corev1alpha1.AdvisorTypePredicate {
+		d.startPriorityStage(instance, predicated)
+		setDeciding(instance, replicas)
+
+		klog.Info("New Status: ", instance.Status)
+
+		return true
+	}
+
 	// if valid decision candidates less than target, ignore priority advisors
 	if len(decisions) > replicas {
 		decisions = d.filterByAdvisorType(decisions, instance.Spec.Advisors, instance.Status.Recommendations, corev1alpha1.AdvisorTypePriority)
@@ -68,16 +128,62 @@ func (d *DefaultDecisionMaker) ContinueDecisionMakingProcess(instance *corev1alp
 	}
 
 	if len(decisions) == replicas || len(instance.Status.Candidates) <= replicas {
-		return d.checkAndSetDecisions(decisions, instance)
+		changed = d.checkAndSetDecisions(decisions, instance) || changed
+		changed = d.recordScores(instance, predicated) || changed
+
+		return setDecided(instance) || changed
 	}
 
-	d.reduceCandidates(instance)
+	if decisionMode(instance) == corev1alpha1.DecisionModeSinglePass {
+		if !d.decideInSinglePass(instance, replicas) {
+			return setDeciding(instance, replicas) || changed
+		}
+
+		d.checkAndSetDecisions(instance.Status.Candidates, instance)
+		d.recordScores(instance, instance.Status.Candidates)
+		setDecided(instance)
+
+		klog.Info("New Status: ", instance.Status)
+
+		return true
+	}
+
+	changed = d.recordScores(instance, predicated) || changed
+
+	// the round goes on until every priority advisor has recommended
+	if !d.reduceCandidates(instance, replicas) {
+		return setDeciding(instance, replicas) || changed
+	}
+
+	setDeciding(instance, replicas)
 
 	klog.Info("New Status: ", instance.Status)
 
 	return true
 }
 
+// predicated returns the candidates passing the predicate advisors of the rule except the relaxed ones,
+// the candidates of the priority stage have passed them in the predicate stage
+func (d *DefaultDecisionMaker) predicated(instance *corev1alpha1.PlacementRule) []corev1.ObjectReference {
+	if instance.Status.Stage == corev1alpha1.AdvisorTypePriority {
+		return instance.Status.Candidates
+	}
+
+	return d.filterByAdvisorType(instance.Status.Candidates, unrelaxedAdvisors(instance), instance.Status.Recommendations,
+		corev1alpha1.AdvisorTypePredicate)
+}
+
+// startPriorityStage eliminates the candidates vetoed in the predicate stage and starts a new round
+// asking the priority advisors to score the predicated candidates
+func (d *DefaultDecisionMaker) startPriorityStage(instance *corev1alpha1.PlacementRule, predicated []corev1.ObjectReference) {
+	if len(predicated) < len(instance.Status.Candidates) {
+		d.eliminateVetoed(instance, predicated)
+	}
+
+	instance.Status.Stage = corev1alpha1.AdvisorTypePriority
+	nextRound(instance)
+}
+
 func (d *DefaultDecisionMaker) filterByAdvisorType(candidates []corev1.ObjectReference,
 	advisors []corev1alpha1.Advisor, recommendations map[string]corev1alpha1.Recommendation, advtype corev1alpha1.AdvisorType) []corev1.ObjectReference {
 	decisions := candidates
@@ -88,9 +194,10 @@ func (d *DefaultDecisionMaker) filterByAdvisorType(candidates []corev1.ObjectRef
 			adv.Type = &t
 		}
 
+		// an empty recommendation of a predicate advisor vetoes every candidate
 		if *adv.Type == advtype {
 			recmap := make(map[types.UID]bool)
-			for _, or := range recommendations[adv.Name] {
+			for _, or := range recommendations[adv.InstanceID()] {
 				recmap[or.UID] = true
 			}
 
@@ -109,6 +216,48 @@ func (d *DefaultDecisionMaker) filterByAdvisorType(candidates []corev1.ObjectRef
 	return decisions
 }
 
+// vetoedBy maps the candidates vetoed by predicate advisors to the first advisor vetoing them
+func (d *DefaultDecisionMaker) vetoedBy(candidates []corev1.ObjectReference,
+	advisors []corev1alpha1.Advisor, recommendations map[string]corev1alpha1.Recommendation) map[string]string {
+	vetoes := make(map[string]string)
+	remaining := candidates
+
+	for _, adv := range advisors {
+		if adv.Type == nil || *adv.Type != corev1alpha1.AdvisorTypePredicate {
+			continue
+		}
+
+		passed := d.filterByAdvisorType(remaining, []corev1alpha1.Advisor{adv}, recommendations, corev1alpha1.AdvisorTypePredicate)
+
+		passmap := make(map[string]bool)
+		for _, or := range passed {
+			passmap[advisorutils.GenKey(or)] = true
+		}
+
+		for _, or := range remaining {
+			if !passmap[advisorutils.GenKey(or)] {
+				vetoes[advisorutils.GenKey(or)] = adv.InstanceID()
+			}
+		}
+
+		remaining = passed
+	}
+
+	return vetoes
+}
+
+// countVetoes counts the candidates vetoed by each predicate advisor, a candidate is counted for the first advisor vetoing it
+func (d *DefaultDecisionMaker) countVetoes(candidates []corev1.ObjectReference,
+	advisors []corev1alpha1.Advisor, recommendations map[string]corev1alpha1.Recommendation) map[string]int32 {
+	vetoes := make(map[string]int32)
+
+	for _, advisor := range d.vetoedBy(candidates, advisors, recommendations) {
+		vetoes[advisor]++
+	}
+
+	return vetoes
+}
+
 func (d *DefaultDecisionMaker) checkAndSetDecisions(decisions []corev1.ObjectReference, instance *corev1alpha1.PlacementRule) bool {
 	if advisorutils.EqualDecisions(decisions, instance.Status.Decisions) {
 		return false
@@ -119,26 +268,14 @@ func (d *DefaultDecisionMaker) checkAndSetDecisions(decisions []corev1.ObjectRef
 	return true
 }
 
-func (d *DefaultDecisionMaker) reduceCandidates(instance *corev1alpha1.PlacementRule) {
-	// reduce by predicates first
-	candidates := d.filterByAdvisorType(instance.Status.Candidates, instance.Spec.Advisors, instance.Status.Recommendations, corev1alpha1.AdvisorTypePredicate)
-	cadweightmap := make(map[string]int)
+// scoreCandidates computes the score breakdown of the candidates from the priority recommendations and the earlier decisions,
+// returns false if a priority advisor recommended nothing
+func (d *DefaultDecisionMaker) scoreCandidates(instance *corev1alpha1.PlacementRule,
+	candidates []corev1.ObjectReference) (map[string]*corev1alpha1.CandidateScore, bool) {
+	scores := make(map[string]*corev1alpha1.CandidateScore)
 
 	for _, or := range candidates {
-		cadweightmap[advisorutils.GenKey(or)] = 0
-	}
-
-	for _, or := range instance.Status.Candidates {
-		if _, ok := cadweightmap[advisorutils.GenKey(or)]; !ok {
-			instance.Status.Eliminators = append(instance.Status.Eliminators, *or.DeepCopy())
-		}
-	}
-
-	if len(candidates) < len(instance.Status.Candidates) {
-		instance.Status.Candidates = candidates
-		instance.Status.Recommendations = nil
-
-		return
+		scores[advisorutils.GenKey(or)] = &corev1alpha1.CandidateScore{ObjectReference: *or.DeepCopy(), Round: instance.Status.Round}
 	}
 
 	// calculate weight of all candidates
@@ -149,74 +286,299 @@ func (d *DefaultDecisionMaker) reduceCandidates(instance *corev1alpha1.Placement
 		}
 
 		if *adv.Type == corev1alpha1.AdvisorTypePriority {
-			rec := instance.Status.Recommendations[adv.Name]
-			if len(rec) == 0 {
-				return
+			// an empty recommendation scores no candidate
+			rec, ok := instance.Status.Recommendations[adv.InstanceID()]
+			if !ok {
+				return scores, false
 			}
 
-			weight := corev1alpha1.DefaultAdvisorWeight
+			weight := int16(corev1alpha1.DefaultAdvisorWeight)
 			if adv.Weight != nil {
-				weight = int(*adv.Weight)
+				weight = *adv.Weight
 			}
 
+			minScore, maxScore := advisorutils.ScoreRange(&adv)
+
 			for _, or := range rec {
-				if _, ok := cadweightmap[advisorutils.GenKey(or.ObjectReference)]; ok {
-					//scored recommendations
-					if or.Score != nil {
-						newweight := (*or.Score / 100) * int16(weight)
-						cadweightmap[advisorutils.GenKey(or.ObjectReference)] += int(newweight)
-					} else {
-						cadweightmap[advisorutils.GenKey(or.ObjectReference)] += weight
-					}
+				cs, ok := scores[advisorutils.GenKey(or.ObjectReference)]
+				if !ok {
+					continue
+				}
+
+				// unscored recommendations get the full weight
+				score := maxScore
+				if or.Score != nil {
+					score = *or.Score
 				}
+
+				as := corev1alpha1.AdvisorScore{Advisor: adv.InstanceID(), Score: score, Weight: weight, Value: normalizeScore(score, minScore, maxScore, weight)}
+
+				cs.Advisors = append(cs.Advisors, as)
+				cs.Total = addScore(cs.Total, as.Value)
 			}
 		}
 	}
 
-	weight := corev1alpha1.DefaultDecisionWeight
+	weight := int64(corev1alpha1.DefaultDecisionWeight)
 	if instance.Spec.DecisionWeight != nil {
-		weight = int(*instance.Spec.DecisionWeight)
+		weight = int64(*instance.Spec.DecisionWeight)
 	}
 
 	for _, or := range instance.Status.Decisions {
-		if _, ok := cadweightmap[advisorutils.GenKey(or)]; ok {
-			cadweightmap[advisorutils.GenKey(or)] += weight
+		if cs, ok := scores[advisorutils.GenKey(or)]; ok {
+			cs.Decision = weight * corev1alpha1.ScorePrecision
+			cs.Total = addScore(cs.Total, cs.Decision)
+		}
+	}
+
+	return scores, true
+}
+
+// recordScores records the score breakdown of the candidates in the current round, highest total first
+func (d *DefaultDecisionMaker) recordScores(instance *corev1alpha1.PlacementRule, candidates []corev1.ObjectReference) bool {
+	scores, ok := d.scoreCandidates(instance, candidates)
+	if !ok {
+		return false
+	}
+
+	var nscores []corev1alpha1.CandidateScore
+
+	for _, cs := range scores {
+		nscores = append(nscores, *cs)
+	}
+
+	tb := newTieBreaker(instance, candidates, d.targets)
+
+	sort.Slice(nscores, func(i, j int) bool {
+		if nscores[i].Total != nscores[j].Total {
+			return nscores[i].Total > nscores[j].Total
 		}
+
+		return tb.prefer(nscores[i].ObjectReference, nscores[j].ObjectReference)
+	})
+
+	if reflect.DeepEqual(nscores, instance.Status.Scores) {
+		return false
+	}
+
+	instance.Status.Scores = nscores
+
+	return true
+}
+
+// reduceCandidates eliminates candidates and starts a new round of recommendations,
+// returns false without starting a round if the candidates could not be scored
+func (d *DefaultDecisionMaker) reduceCandidates(instance *corev1alpha1.PlacementRule, replicas int) bool {
+	// negative replicas are rejected by the CRD validation, never keep fewer than no candidate
+	if replicas < 0 {
+		replicas = 0
+	}
+
+	// reduce by predicates first
+	candidates := d.predicated(instance)
+
+	if len(candidates) < len(instance.Status.Candidates) {
+		d.eliminateVetoed(instance, candidates)
+		nextRound(instance)
+
+		return true
+	}
+
+	scores, ok := d.scoreCandidates(instance, candidates)
+	if !ok {
+		return false
 	}
 
 	// reduce lowest n candidates
-	eliminationMap := make(map[string]corev1.ObjectReference)
-	step := d.calculateStep()
+	ranked := d.rankCandidates(instance, instance.Status.Candidates, scores)
 
-	var newcandidates []corev1.ObjectReference
+	step := d.calculateStep(instance.Spec.Step, len(ranked), replicas)
 
-	for _, or := range instance.Status.Candidates {
-		c := *or.DeepCopy()
+	d.eliminateRanked(instance, ranked, len(ranked)-step, scores)
+	nextRound(instance)
+
+	return true
+}
+
+// nextRound clears the recommendations of the current round and starts the next one
+func nextRound(instance *corev1alpha1.PlacementRule) {
+	instance.Status.Recommendations = nil
+	instance.Status.RecommendationRounds = nil
+	instance.Status.AdvisorErrors = nil
+	instance.Status.Round++
+}
+
+// decideInSinglePass ranks the candidates on the recommendations of the current round and keeps the top replicas,
+// returns false if the candidates could not be scored
+func (d *DefaultDecisionMaker) decideInSinglePass(instance *corev1alpha1.PlacementRule, replicas int) bool {
+	candidates := d.predicated(instance)
+
+	scores, ok := d.scoreCandidates(instance, candidates)
+	if !ok {
+		return false
+	}
+
+	if len(candidates) < len(instance.Status.Candidates) {
+		d.eliminateVetoed(instance, candidates)
+	}
+
+	ranked := d.rankCandidates(instance, candidates, scores)
+	if replicas > len(ranked) {
+		replicas = len(ranked)
+	}
 
-		if len(eliminationMap) < step {
-			eliminationMap[advisorutils.GenKey(c)] = c
+	if replicas < 0 {
+		replicas = 0
+	}
+
+	d.eliminateRanked(instance, ranked, replicas, scores)
+
+	return true
+}
+
+// eliminateVetoed eliminates the candidates vetoed by predicate advisors, keeping the predicated candidates
+func (d *DefaultDecisionMaker) eliminateVetoed(instance *corev1alpha1.PlacementRule, predicated []corev1.ObjectReference) {
+	vetoes := d.vetoedBy(instance.Status.Candidates, unrelaxedAdvisors(instance), instance.Status.Recommendations)
+
+	for _, or := range instance.Status.Candidates {
+		advisor, ok := vetoes[advisorutils.GenKey(or)]
+		if !ok {
 			continue
 		}
 
-		for k, el := range eliminationMap {
-			if cadweightmap[advisorutils.GenKey(el)] > cadweightmap[advisorutils.GenKey(c)] {
-				delete(eliminationMap, k)
-				eliminationMap[advisorutils.GenKey(c)] = c
-				c = el
-			}
+		instance.Status.Eliminators = append(instance.Status.Eliminators, *or.DeepCopy())
+		instance.Status.Eliminations = append(instance.Status.Eliminations, corev1alpha1.CandidateScore{
+			ObjectReference: *or.DeepCopy(),
+			Round:           instance.Status.Round,
+			VetoedBy:        advisor,
+		})
+	}
+
+	instance.Status.Candidates = predicated
+
+	eliminateVetoes(instance)
+}
+
+// rankCandidates orders the candidates by total score, highest first, ties are broken by the policy of the rule
+func (d *DefaultDecisionMaker) rankCandidates(instance *corev1alpha1.PlacementRule, candidates []corev1.ObjectReference,
+	scores map[string]*corev1alpha1.CandidateScore) []corev1.ObjectReference {
+	tb := newTieBreaker(instance, candidates, d.targets)
+
+	ranked := make([]corev1.ObjectReference, len(candidates))
+	copy(ranked, candidates)
+
+	sort.SliceStable(ranked, func(i, j int) bool {
+		si, sj := scores[advisorutils.GenKey(ranked[i])].Total, scores[advisorutils.GenKey(ranked[j])].Total
+		if si != sj {
+			return si > sj
 		}
 
-		newcandidates = append(newcandidates, c)
+		return tb.prefer(ranked[i], ranked[j])
+	})
+
+	return ranked
+}
+
+// eliminateRanked keeps the first n ranked candidates in their current order and eliminates the others, lowest first
+func (d *DefaultDecisionMaker) eliminateRanked(instance *corev1alpha1.PlacementRule, ranked []corev1.ObjectReference, n int,
+	scores map[string]*corev1alpha1.CandidateScore) {
+	keep := make(map[string]bool)
+	for _, or := range ranked[:n] {
+		keep[advisorutils.GenKey(or)] = true
+	}
+
+	var newcandidates []corev1.ObjectReference
+
+	for _, or := range instance.Status.Candidates {
+		if keep[advisorutils.GenKey(or)] {
+			newcandidates = append(newcandidates, *or.DeepCopy())
+		}
 	}
 
 	instance.Status.Candidates = newcandidates
-	instance.Status.Recommendations = nil
 
-	for _, or := range eliminationMap {
+	for i := len(ranked) - 1; i >= n; i-- {
+		or := ranked[i]
 		instance.Status.Eliminators = append(instance.Status.Eliminators, *or.DeepCopy())
+		instance.Status.Eliminations = append(instance.Status.Eliminations, *scores[advisorutils.GenKey(or)].DeepCopy())
+	}
+}
+
+// normalizeScore returns the share of weight for a score in [minScore, maxScore], in 1/ScorePrecision points.
+// Scores out of the range are clamped.
+func normalizeScore(score, minScore, maxScore, weight int16) int64 {
+	if score < minScore {
+		score = minScore
+	}
+
+	if score > maxScore {
+		score = maxScore
+	}
+
+	// at most 65535 * 32768 * 1000 before the division, far from overflowing int64
+	return (int64(score) - int64(minScore)) * int64(weight) * corev1alpha1.ScorePrecision / (int64(maxScore) - int64(minScore))
+}
+
+// addScore adds a value to a total, saturating instead of overflowing
+func addScore(total, value int64) int64 {
+	switch {
+	case value > 0 && total > math.MaxInt64-value:
+		return math.MaxInt64
+	case value < 0 && total < math.MinInt64-value:
+		return math.MinInt64
 	}
+
+	return total + value
 }
 
-func (d *DefaultDecisionMaker) calculateStep() int {
-	return defaultStep
+func decisionMode(instance *corev1alpha1.PlacementRule) corev1alpha1.DecisionMode {
+	if instance.Spec.DecisionMode == nil {
+		return corev1alpha1.DecisionModeIterative
+	}
+
+	return *instance.Spec.DecisionMode
+}
+
+// calculateStep returns the number of candidates to eliminate in this round, between 1 and the candidates in excess of the replicas
+func (d *DefaultDecisionMaker) calculateStep(policy *corev1alpha1.EliminationStep, candidates, replicas int) int {
+	if replicas < 0 {
+		replicas = 0
+	}
+
+	excess := candidates - replicas
+	if excess <= defaultStep {
+		return defaultStep
+	}
+
+	step := defaultStep
+
+	switch {
+	case policy == nil:
+	case policy.Policy == corev1alpha1.StepPolicyAdaptive:
+		// halve the excess candidates, converging in log2(candidates/replicas) rounds
+		step = (excess + 1) / 2
+	case policy.Size != nil:
+		size, err := intstr.GetValueFromIntOrPercent(policy.Size, candidates, true)
+		if err != nil {
+			klog.Warning("Invalid step size ", policy.Size.String(), ", eliminating ", defaultStep, " candidate per round: ", err)
+			break
+		}
+
+		step = size
+	}
+
+	if step < defaultStep {
+		step = defaultStep
+	}
+
+	if step > excess {
+		step = excess
+	}
+
+	// never eliminate more candidates than the rule has
+	if step > candidates {
+		step = candidates
+	}
+
+	return step
 }
@@ -0,0 +1,129 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"k8s.io/klog"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+const (
+	// deployerTypeIndex indexes deployers by spec.type in the manager cache
+	deployerTypeIndex = "spec.type"
+	// ruleDeployerTypeIndex indexes placement rules by spec.deployerType in the manager cache
+	ruleDeployerTypeIndex = "spec.deployerType"
+	// recommendationRuleIndex indexes PlacementRecommendations by spec.placementRef.name in the manager cache
+	recommendationRuleIndex = "spec.placementRef.name"
+	// noDeployerType is the index value of placement rules without spec.deployerType, placed on the default target
+	noDeployerType = ""
+)
+
+// fieldIndexPrefix prefixes the names of field indexes in the informers of the manager cache, like controller-runtime does
+const fieldIndexPrefix = "field:"
+
+// addIndexes indexes deployers and rules by deployer type and recommendations by rule in the manager cache, once per manager:
+// engines added to the same manager share the indexes
+func addIndexes(mgr manager.Manager) error {
+	err := addIndex(mgr, &corev1alpha1.Deployer{}, deployerTypeIndex, func(obj runtime.Object) []string {
+		dply := obj.(*corev1alpha1.Deployer)
+		return []string{dply.Spec.Type}
+	})
+	if err != nil {
+		return err
+	}
+
+	err = addIndex(mgr, &corev1alpha1.PlacementRule{}, ruleDeployerTypeIndex, func(obj runtime.Object) []string {
+		instance := obj.(*corev1alpha1.PlacementRule)
+		if instance.Spec.DeployerType == nil {
+			return []string{noDeployerType}
+		}
+
+		return []string{*instance.Spec.DeployerType}
+	})
+	if err != nil {
+		return err
+	}
+
+	return addIndex(mgr, &corev1alpha1.PlacementRecommendation{}, recommendationRuleIndex, func(obj runtime.Object) []string {
+		rec := obj.(*corev1alpha1.PlacementRecommendation)
+		return []string{rec.Spec.PlacementRef.Name}
+	})
+}
+
+// addIndex indexes obj by field in the manager cache unless its informer already has the index
+func addIndex(mgr manager.Manager, obj runtime.Object, field string, extractValue client.IndexerFunc) error {
+	informer, err := mgr.GetCache().GetInformer(context.TODO(), obj)
+	if err != nil {
+		return err
+	}
+
+	if indexer, ok := informer.(toolscache.SharedIndexInformer); ok {
+		if _, indexed := indexer.GetIndexer().GetIndexers()[fieldIndexPrefix+field]; indexed {
+			return nil
+		}
+	}
+
+	return mgr.GetFieldIndexer().IndexField(context.TODO(), obj, field, extractValue)
+}
+
+// deployerRuleMapper maps a deployer to the placement rules of its deployer type
+type deployerRuleMapper struct {
+	client  client.Client
+	watcher *targetWatcher
+	rules   labels.Selector
+}
+
+var _ handler.Mapper = &deployerRuleMapper{}
+
+func (m *deployerRuleMapper) Map(obj handler.MapObject) []reconcile.Request {
+	dply, ok := obj.Object.(*corev1alpha1.Deployer)
+	if !ok {
+		return nil
+	}
+
+	rules := &corev1alpha1.PlacementRuleList{}
+
+	err := m.client.List(context.TODO(), rules, client.MatchingFields{ruleDeployerTypeIndex: dply.Spec.Type},
+		client.MatchingLabelsSelector{Selector: m.rules})
+	if err != nil {
+		klog.Error("Failed to list placement rules for deployer type ", dply.Spec.Type, " with error: ", err)
+		return nil
+	}
+
+	var requests []reconcile.Request
+
+	for i := range rules.Items {
+		instance := &rules.Items[i]
+
+		// the placement target of the deployer might not be watched yet
+		m.watcher.watchTargetsOf(instance)
+
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}})
+	}
+
+	return requests
+}
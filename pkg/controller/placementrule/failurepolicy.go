@@ -0,0 +1,309 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"reflect"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// startRound starts the advisor timeouts of the current decision round, truncated to the precision of the status
+func startRound(instance *corev1alpha1.PlacementRule, now time.Time) {
+	start := metav1.NewTime(now.Truncate(time.Second))
+	instance.Status.RoundStartTime = &start
+}
+
+// advisorDeadline returns the end of the advisor timeout in the current round, false if the advisor is waited for indefinitely
+func advisorDeadline(instance *corev1alpha1.PlacementRule, adv *corev1alpha1.Advisor) (time.Time, bool) {
+	if adv.Timeout == nil || instance.Status.RoundStartTime == nil {
+		return time.Time{}, false
+	}
+
+	return instance.Status.RoundStartTime.Add(adv.Timeout.Duration), true
+}
+
+// failurePolicy returns the failure policy of an advisor, advisors fail the rule by default
+func failurePolicy(adv *corev1alpha1.Advisor) corev1alpha1.AdvisorFailurePolicy {
+	if adv.FailurePolicy == nil {
+		return corev1alpha1.AdvisorFailurePolicyFail
+	}
+
+	return *adv.FailurePolicy
+}
+
+// timedOutAdvisors returns the advisors of the current stage of the rule past their deadline without recommendation
+// in the current round
+func timedOutAdvisors(instance *corev1alpha1.PlacementRule, now time.Time) []corev1alpha1.Advisor {
+	var timedout []corev1alpha1.Advisor
+
+	for _, adv := range instance.Spec.Advisors {
+		if !advisorutils.InStage(instance, &adv) {
+			continue
+		}
+
+		if _, ok := instance.Status.Recommendations[adv.InstanceID()]; ok {
+			continue
+		}
+
+		if deadline, ok := advisorDeadline(instance, &adv); ok && !now.Before(deadline) {
+			timedout = append(timedout, adv)
+		}
+	}
+
+	return timedout
+}
+
+// failedAdvisors returns the timed out advisors holding the decisions of the rule
+func failedAdvisors(instance *corev1alpha1.PlacementRule, now time.Time) []string {
+	var failed []string
+
+	for _, adv := range timedOutAdvisors(instance, now) {
+		if failurePolicy(&adv) == corev1alpha1.AdvisorFailurePolicyFail {
+			failed = append(failed, adv.InstanceID())
+		}
+	}
+
+	return failed
+}
+
+// erroredAdvisors returns the predicate advisors of the rule reporting errors instead of recommending, as advisor: error
+func erroredAdvisors(instance *corev1alpha1.PlacementRule) []string {
+	var errored []string
+
+	for _, adv := range instance.Spec.Advisors {
+		if adv.Type == nil || *adv.Type != corev1alpha1.AdvisorTypePredicate || !advisorutils.InStage(instance, &adv) {
+			continue
+		}
+
+		if _, ok := instance.Status.Recommendations[adv.InstanceID()]; ok {
+			continue
+		}
+
+		if aerr, ok := instance.Status.AdvisorErrors[adv.InstanceID()]; ok {
+			errored = append(errored, adv.InstanceID()+": "+aerr.Message)
+		}
+	}
+
+	return errored
+}
+
+// applyFailurePolicies recommends for the timed out advisors of the rule as their failure policies tell, and ignores
+// the priority advisors reporting errors, returns the policy applied to each advisor recommended for.
+// Advisors failing the rule and predicate advisors reporting errors are left pending.
+func applyFailurePolicies(instance *corev1alpha1.PlacementRule, now time.Time) map[string]corev1alpha1.AdvisorFailurePolicy {
+	applied := make(map[string]corev1alpha1.AdvisorFailurePolicy)
+
+	for _, adv := range timedOutAdvisors(instance, now) {
+		policy := failurePolicy(&adv)
+		if policy == corev1alpha1.AdvisorFailurePolicyFail {
+			continue
+		}
+
+		rec, known := lastKnownRecommendation(instance, adv.InstanceID())
+		if policy != corev1alpha1.AdvisorFailurePolicyUseLastKnown || !known {
+			policy = corev1alpha1.AdvisorFailurePolicyIgnore
+			rec = neutralRecommendation(instance, &adv)
+		}
+
+		klog.Info("Advisor ", adv.InstanceID(), " timed out in round ", instance.Status.Round, " of placement rule ",
+			instance.Namespace+"/"+instance.Name, ", applying failure policy ", policy)
+
+		if instance.Status.Recommendations == nil {
+			instance.Status.Recommendations = make(map[string]corev1alpha1.Recommendation)
+		}
+
+		instance.Status.Recommendations[adv.InstanceID()] = rec
+		applied[adv.InstanceID()] = policy
+	}
+
+	for _, adv := range instance.Spec.Advisors {
+		if (adv.Type != nil && *adv.Type == corev1alpha1.AdvisorTypePredicate) || !advisorutils.InStage(instance, &adv) {
+			continue
+		}
+
+		if _, ok := instance.Status.Recommendations[adv.InstanceID()]; ok {
+			continue
+		}
+
+		aerr, ok := instance.Status.AdvisorErrors[adv.InstanceID()]
+		if !ok {
+			continue
+		}
+
+		klog.Info("Advisor ", adv.InstanceID(), " failed in round ", instance.Status.Round, " of placement rule ",
+			instance.Namespace+"/"+instance.Name, ", ignoring it: ", aerr.Message)
+
+		if instance.Status.Recommendations == nil {
+			instance.Status.Recommendations = make(map[string]corev1alpha1.Recommendation)
+		}
+
+		instance.Status.Recommendations[adv.InstanceID()] = neutralRecommendation(instance, &adv)
+		applied[adv.InstanceID()] = corev1alpha1.AdvisorFailurePolicyIgnore
+	}
+
+	return applied
+}
+
+// neutralRecommendation recommends every candidate with the minimum score of the advisor, it neither vetoes nor ranks them
+func neutralRecommendation(instance *corev1alpha1.PlacementRule, adv *corev1alpha1.Advisor) corev1alpha1.Recommendation {
+	minScore, _ := advisorutils.ScoreRange(adv)
+
+	var rec corev1alpha1.Recommendation
+
+	for _, or := range instance.Status.Candidates {
+		score := minScore
+		rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: *or.DeepCopy(), Score: &score})
+	}
+
+	return rec
+}
+
+// lastKnownRecommendation returns the last known recommendation of an advisor limited to the current candidates,
+// false if the advisor never recommended
+func lastKnownRecommendation(instance *corev1alpha1.PlacementRule, advisor string) (corev1alpha1.Recommendation, bool) {
+	last, ok := instance.Status.LastKnownRecommendations[advisor]
+	if !ok {
+		return nil, false
+	}
+
+	candidates := make(map[string]bool)
+	for _, or := range instance.Status.Candidates {
+		candidates[advisorutils.GenKey(or)] = true
+	}
+
+	rec := corev1alpha1.Recommendation{}
+
+	for _, or := range last {
+		if candidates[advisorutils.GenKey(or.ObjectReference)] {
+			rec = append(rec, *or.DeepCopy())
+		}
+	}
+
+	return rec, true
+}
+
+// rememberRecommendations keeps the recommendations of advisors with the UseLastKnown failure policy for later rounds,
+// returns true if the last known recommendations are changed
+func rememberRecommendations(instance *corev1alpha1.PlacementRule) bool {
+	lastKnown := make(map[string]corev1alpha1.Recommendation)
+
+	for _, adv := range instance.Spec.Advisors {
+		if failurePolicy(&adv) != corev1alpha1.AdvisorFailurePolicyUseLastKnown {
+			continue
+		}
+
+		if rec, ok := instance.Status.Recommendations[adv.InstanceID()]; ok {
+			lastKnown[adv.InstanceID()] = advisorutils.NonNilRecommendation(rec.DeepCopy())
+		} else if rec, ok := instance.Status.LastKnownRecommendations[adv.InstanceID()]; ok {
+			lastKnown[adv.InstanceID()] = rec
+		}
+	}
+
+	if len(lastKnown) == 0 {
+		lastKnown = nil
+	}
+
+	if reflect.DeepEqual(lastKnown, instance.Status.LastKnownRecommendations) {
+		return false
+	}
+
+	instance.Status.LastKnownRecommendations = lastKnown
+
+	return true
+}
+
+// setAdvisorStatuses records the state of each advisor of the rule in the current round, returns true if a state is changed
+func setAdvisorStatuses(instance *corev1alpha1.PlacementRule, applied map[string]corev1alpha1.AdvisorFailurePolicy, now time.Time) bool {
+	var statuses []corev1alpha1.AdvisorStatus
+
+	for _, adv := range instance.Spec.Advisors {
+		as := corev1alpha1.AdvisorStatus{Name: adv.Name, ID: adv.InstanceID(), State: corev1alpha1.AdvisorStatePending}
+
+		if !advisorutils.InStage(instance, &adv) {
+			as.State = corev1alpha1.AdvisorStateIdle
+			statuses = append(statuses, as)
+
+			continue
+		}
+
+		deadline, ok := advisorDeadline(instance, &adv)
+		if ok {
+			as.Deadline = &metav1.Time{Time: deadline}
+		}
+
+		_, recommended := instance.Status.Recommendations[adv.InstanceID()]
+		aerr, failed := instance.Status.AdvisorErrors[adv.InstanceID()]
+
+		policy, substituted := applied[adv.InstanceID()]
+		if !substituted {
+			policy = corev1alpha1.AdvisorFailurePolicyFail
+		}
+
+		switch {
+		case recommended && !substituted:
+			as.State = corev1alpha1.AdvisorStateRecommended
+		case ok && !now.Before(deadline):
+			as.State = corev1alpha1.AdvisorStateTimedOut
+			as.FailurePolicy = policy
+		case failed:
+			as.State = corev1alpha1.AdvisorStateFailed
+			as.FailurePolicy = policy
+		}
+
+		if failed {
+			as.Error = aerr.Message
+		}
+
+		statuses = append(statuses, as)
+	}
+
+	// deadlines read from the API server are in the local time zone
+	if equality.Semantic.DeepEqual(statuses, instance.Status.Advisors) {
+		return false
+	}
+
+	instance.Status.Advisors = statuses
+
+	return true
+}
+
+// nextDeadline returns the time left until the earliest deadline of the pending advisors of the rule, 0 if there is none
+func nextDeadline(instance *corev1alpha1.PlacementRule, now time.Time) time.Duration {
+	var next time.Duration
+
+	for _, adv := range instance.Spec.Advisors {
+		if _, ok := instance.Status.Recommendations[adv.InstanceID()]; ok || !advisorutils.InStage(instance, &adv) {
+			continue
+		}
+
+		deadline, ok := advisorDeadline(instance, &adv)
+		if !ok || !now.Before(deadline) {
+			continue
+		}
+
+		if left := deadline.Sub(now); next == 0 || left < next {
+			next = left
+		}
+	}
+
+	return next
+}
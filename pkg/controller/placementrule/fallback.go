@@ -0,0 +1,137 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"reflect"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// generateFallbackTargets returns the targets matching the fallback of the rule by namespace/name, nil without fallback
+func (r *ReconcilePlacementRule) generateFallbackTargets(instance *corev1alpha1.PlacementRule) ([]corev1.ObjectReference, error) {
+	if instance == nil || instance.Spec.Fallback == nil {
+		return nil, nil
+	}
+
+	gvr, err := getTargetGVR(r.client, instance)
+	if err != nil || gvr == nil {
+		// candidate generation reports the missing target
+		return nil, err
+	}
+
+	tl, err := r.targets.list(*gvr, labels.Everything())
+	if err != nil {
+		klog.Error("Failed to list ", gvr.String(), " with error: ", err)
+		return nil, err
+	}
+
+	var targets []corev1.ObjectReference
+
+	for i := range tl.Items {
+		ok, err := isFallbackTarget(instance, gvr, &tl.Items[i], r.ignoredTargets)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			targets = append(targets, objectReference(&tl.Items[i]))
+		}
+	}
+
+	sort.Slice(targets, func(i, j int) bool {
+		if targets[i].Namespace != targets[j].Namespace {
+			return targets[i].Namespace < targets[j].Namespace
+		}
+
+		return targets[i].Name < targets[j].Name
+	})
+
+	return targets, nil
+}
+
+// isFallbackTarget checks a target object of gvr against ignored targets, the fallback and deployerType of the rule
+func isFallbackTarget(instance *corev1alpha1.PlacementRule, gvr *schema.GroupVersionResource, obj *unstructured.Unstructured,
+	ignoredTargets []corev1.ObjectReference) (bool, error) {
+	if instance.Spec.Fallback == nil {
+		return false, nil
+	}
+
+	// fallback targets are filtered like the targets of the rule
+	fallback := *instance
+	fallback.Spec.Targets = instance.Spec.Fallback.Targets
+	fallback.Spec.TargetLabels = instance.Spec.Fallback.TargetLabels
+
+	return isCandidate(&fallback, gvr, obj, ignoredTargets)
+}
+
+// isKnownFallbackTarget checks whether the target is recorded as a fallback target of the rule
+func isKnownFallbackTarget(instance *corev1alpha1.PlacementRule, uid types.UID) bool {
+	for _, or := range instance.Status.FallbackTargets {
+		if or.UID == uid {
+			return true
+		}
+	}
+
+	return false
+}
+
+// setFallbackTargets records the fallback targets of the rule, returns true if they are changed
+func setFallbackTargets(instance *corev1alpha1.PlacementRule, targets []corev1.ObjectReference) bool {
+	if (len(targets) == 0 && len(instance.Status.FallbackTargets) == 0) || reflect.DeepEqual(targets, instance.Status.FallbackTargets) {
+		return false
+	}
+
+	instance.Status.FallbackTargets = targets
+
+	return true
+}
+
+// fallbackDecisions returns the fallback targets of the rule up to its replicas, nil without fallback
+func fallbackDecisions(instance *corev1alpha1.PlacementRule) []corev1.ObjectReference {
+	if instance.Spec.Fallback == nil || len(instance.Status.FallbackTargets) == 0 {
+		return nil
+	}
+
+	decisions := instance.Status.FallbackTargets
+
+	if instance.Spec.Replicas != nil {
+		replicas := int(*instance.Spec.Replicas)
+		if replicas < 0 {
+			replicas = 0
+		}
+
+		if replicas < len(decisions) {
+			decisions = decisions[:replicas]
+		}
+	}
+
+	if len(decisions) == 0 {
+		return nil
+	}
+
+	result := make([]corev1.ObjectReference, len(decisions))
+	copy(result, decisions)
+
+	return result
+}
@@ -0,0 +1,68 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/hybridapp-io/ham-placement/pkg/advisor"
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+const (
+	defaultControllerName = "placementrule-controller"
+)
+
+// Options configures a placement engine embedded in an operator, zero values fall back to the defaults of this operator
+type Options struct {
+	// Name of the placement rule controller, the advisor controllers of the engine are named after it,
+	// default: placementrule-controller
+	Name string
+	// DecisionMaker decides the rules without spec.decisionMaker, it takes precedence over the DefaultDecisionMakerName
+	// entry of DecisionMakers, default: that entry, else DefaultDecisionMaker
+	DecisionMaker DecisionMaker
+	// DecisionMakers are selected by name in spec.decisionMaker, default: the ones registered with RegisterDecisionMaker
+	DecisionMakers map[string]DecisionMaker
+	// Advisors are added to the manager together with the engine by AddWithOptions and advise the rules
+	// of its RuleSelector only, default: none
+	Advisors *advisor.Registry
+	// IgnoredTargets are never candidates of a rule, default: corev1alpha1.IgnoredTargets
+	IgnoredTargets []corev1.ObjectReference
+	// RuleSelector selects the rules decided by the engine by their labels, engines added to the same manager
+	// should select disjoint rules, default: every rule
+	RuleSelector labels.Selector
+}
+
+// complete returns the options with defaults
+func (o Options) complete() Options {
+	if o.Name == "" {
+		o.Name = defaultControllerName
+	}
+
+	if o.DecisionMakers == nil {
+		o.DecisionMakers = registeredDecisionMakers()
+	}
+
+	if o.IgnoredTargets == nil {
+		o.IgnoredTargets = corev1alpha1.IgnoredTargets
+	}
+
+	if o.RuleSelector == nil {
+		o.RuleSelector = labels.Everything()
+	}
+
+	return o
+}
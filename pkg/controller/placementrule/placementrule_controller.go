@@ -16,24 +16,30 @@ package placementrule
 
 import (
 	"context"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/client-go/dynamic"
 	"k8s.io/klog"
 
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 	"sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/hybridapp-io/ham-placement/pkg/advisor/framework"
 	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
 )
 
+// PlacementDecisionMaker replaces the default decision maker of every rule.
+//
+// Deprecated: register named decision makers with RegisterDecisionMaker and select them in spec.decisionMaker
 var PlacementDecisionMaker DecisionMaker
 
 /**
@@ -47,17 +53,54 @@ func Add(mgr manager.Manager) error {
 	return add(mgr, newReconciler(mgr))
 }
 
+// AddWithOptions creates a placement engine configured by opts and adds its controller and advisors to the Manager,
+// independently of the engines added by Add or with other options.
+func AddWithOptions(mgr manager.Manager, opts Options) error {
+	opts = opts.complete()
+
+	if err := addController(mgr, NewReconciler(mgr, opts), opts); err != nil {
+		return err
+	}
+
+	// the advisors of the engine are named after it and advise the rules of the engine only
+	if opts.Advisors != nil {
+		return opts.Advisors.AddToManager(mgr, framework.WithEngine(opts.Name), framework.WithRuleSelector(opts.RuleSelector))
+	}
+
+	return nil
+}
+
 // newReconciler returns a new reconcile.Reconciler
 func newReconciler(mgr manager.Manager) reconcile.Reconciler {
-	if PlacementDecisionMaker == nil {
-		PlacementDecisionMaker = &DefaultDecisionMaker{}
+	return NewReconciler(mgr, Options{DecisionMaker: PlacementDecisionMaker})
+}
+
+// NewReconciler returns a PlacementRule reconciler configured by opts, without global state other than
+// the decision maker registry used when opts.DecisionMakers is nil
+func NewReconciler(mgr manager.Manager, opts Options) *ReconcilePlacementRule {
+	opts = opts.complete()
+	targets := &targetCache{cache: mgr.GetCache(), mapper: mgr.GetRESTMapper()}
+
+	decisionMakers := make(map[string]DecisionMaker, len(opts.DecisionMakers)+1)
+	for name, dm := range opts.DecisionMakers {
+		decisionMakers[name] = dm
+	}
+
+	// opts.DecisionMaker overrides a default decision maker of opts.DecisionMakers,
+	// the built-in one reads target ages from the cache of this manager
+	if opts.DecisionMaker != nil {
+		decisionMakers[DefaultDecisionMakerName] = opts.DecisionMaker
+	} else if _, ok := decisionMakers[DefaultDecisionMakerName]; !ok {
+		decisionMakers[DefaultDecisionMakerName] = &DefaultDecisionMaker{targets: targets}
 	}
 
 	rec := &ReconcilePlacementRule{
-		client:        mgr.GetClient(),
-		scheme:        mgr.GetScheme(),
-		dynamicClient: dynamic.NewForConfigOrDie(mgr.GetConfig()),
-		decisionMaker: PlacementDecisionMaker,
+		client:         mgr.GetClient(),
+		scheme:         mgr.GetScheme(),
+		targets:        targets,
+		decisionMakers: decisionMakers,
+		ignoredTargets: opts.IgnoredTargets,
+		ruleSelector:   opts.RuleSelector,
 	}
 
 	return rec
@@ -65,14 +108,52 @@ func newReconciler(mgr manager.Manager) reconcile.Reconciler {
 
 // add adds a new Controller to mgr with r as the reconcile.Reconciler
 func add(mgr manager.Manager, r reconcile.Reconciler) error {
+	return addController(mgr, r, Options{}.complete())
+}
+
+// addController adds a new Controller named by opts to mgr with r as the reconcile.Reconciler
+func addController(mgr manager.Manager, r reconcile.Reconciler, opts Options) error {
+	// index the cache before the controller starts informers on it
+	err := addIndexes(mgr)
+	if err != nil {
+		return err
+	}
+
 	// Create a new controller
-	c, err := controller.New("placementrule-controller", mgr, controller.Options{Reconciler: r})
+	c, err := controller.New(opts.Name, mgr, controller.Options{Reconciler: r})
 	if err != nil {
 		return err
 	}
 
+	// the engine watches the rules of its rule selector only
+	selected := predicate.NewPredicateFuncs(func(meta metav1.Object, _ runtime.Object) bool {
+		return opts.RuleSelector.Matches(labels.Set(meta.GetLabels()))
+	})
+
 	// Watch for changes to primary resource PlacementRule
-	err = c.Watch(&source.Kind{Type: &corev1alpha1.PlacementRule{}}, &handler.EnqueueRequestForObject{})
+	err = c.Watch(&source.Kind{Type: &corev1alpha1.PlacementRule{}}, &handler.EnqueueRequestForObject{}, selected)
+	if err != nil {
+		return err
+	}
+
+	watcher := newTargetWatcher(c, mgr.GetClient(), mgr.GetRESTMapper(), opts.IgnoredTargets, opts.RuleSelector)
+
+	// Watch for changes to placement targets, started for every target resource referred by a PlacementRule
+	err = c.Watch(&source.Kind{Type: &corev1alpha1.PlacementRule{}}, &ruleTargetHandler{watcher: watcher}, selected)
+	if err != nil {
+		return err
+	}
+
+	// Watch for recommendations of advisors writing PlacementRecommendations instead of the rule status
+	err = c.Watch(&source.Kind{Type: &corev1alpha1.PlacementRecommendation{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: &recommendationRuleMapper{}})
+	if err != nil {
+		return err
+	}
+
+	// Watch for changes to deployers, which define the placement targets of a deployer type
+	err = c.Watch(&source.Kind{Type: &corev1alpha1.Deployer{}},
+		&handler.EnqueueRequestsFromMapFunc{ToRequests: &deployerRuleMapper{client: mgr.GetClient(), watcher: watcher, rules: opts.RuleSelector}})
 	if err != nil {
 		return err
 	}
@@ -85,10 +166,12 @@ var _ reconcile.Reconciler = &ReconcilePlacementRule{}
 
 // ReconcilePlacementRule reconciles a PlacementRule object
 type ReconcilePlacementRule struct {
-	client        client.Client
-	scheme        *runtime.Scheme
-	dynamicClient dynamic.Interface
-	decisionMaker DecisionMaker
+	client         client.Client
+	scheme         *runtime.Scheme
+	targets        *targetCache
+	decisionMakers map[string]DecisionMaker // key: decision maker name
+	ignoredTargets []corev1.ObjectReference
+	ruleSelector   labels.Selector
 }
 
 // Reconcile reads that state of the cluster for a PlacementRule object and makes changes based on the state read
@@ -114,15 +197,66 @@ func (r *ReconcilePlacementRule) Reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, err
 	}
 
+	// rules mapped from targets, deployers or recommendations might be decided by another engine
+	if !r.ruleSelector.Matches(labels.Set(instance.Labels)) {
+		return reconcile.Result{}, nil
+	}
+
 	// Step 1: generate new candidates from spec
-	ncans, err := r.generateCandidates(instance)
+	ncans, summary, err := r.generateCandidates(instance)
+
+	var fallback []corev1.ObjectReference
+	if err == nil {
+		fallback, err = r.generateFallbackTargets(instance)
+	}
+
 	if err != nil {
 		klog.Error("Failed to generate candidates for decision with error: ", err)
+
+		// keep candidates and decisions of the last good reconcile, the error requeues the request with backoff
+		if setDegraded(instance, err) {
+			if uerr := r.client.Status().Update(context.TODO(), instance); uerr != nil {
+				klog.Error("Failed to update degraded status with error: ", uerr)
+			}
+		}
+
+		return reconcile.Result{}, err
+	}
+
+	changed := setDegraded(instance, nil)
+
+	if setFallbackTargets(instance, fallback) {
+		changed = true
+	}
+
+	// keep decisions until the rule selects a registered decision maker, a spec change reconciles it again
+	dm, err := r.decisionMakerFor(instance)
+	if err != nil {
+		klog.Error("Failed to get decision maker for placement rule ", request.NamespacedName, " with error: ", err)
+
+		if setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse,
+			corev1alpha1.PlacementRuleReasonUnknownDecisionMaker, err.Error()) || changed {
+			return reconcile.Result{}, r.client.Status().Update(context.TODO(), instance)
+		}
+
+		return reconcile.Result{}, nil
+	}
+
+	// keep decisions until the advisors of the rule have unique instance ids, a spec change reconciles it again
+	if err = validateAdvisors(instance); err != nil {
+		klog.Error("Invalid advisors in placement rule ", request.NamespacedName, ": ", err)
+
+		if setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse,
+			corev1alpha1.PlacementRuleReasonInvalidAdvisors, err.Error()) || changed {
+			return reconcile.Result{}, r.client.Status().Update(context.TODO(), instance)
+		}
+
+		return reconcile.Result{}, nil
 	}
 
 	// if spec has been changed, reset it
 	if instance.Status.ObservedGeneration != instance.GetGeneration() || !isSameCandidateList(ncans, instance) {
-		err = r.resetDecisionMakingProcess(ncans, instance)
+		err = r.resetDecisionMakingProcess(dm, ncans, summary, instance)
 		if err != nil {
 			klog.Error("Following error occurred during resetDecisionMakingProcess: ", err)
 		}
@@ -130,38 +264,112 @@ func (r *ReconcilePlacementRule) Reconcile(request reconcile.Request) (reconcile
 		return reconcile.Result{}, err
 	}
 
-	return reconcile.Result{}, r.continueDecisionMakingProcess(instance)
+	if setTargetSummary(instance, summary) {
+		changed = true
+	}
+
+	if discardStaleRecommendations(instance) {
+		changed = true
+	}
+
+	if removeEmptyRecommendationReferences(instance) {
+		changed = true
+	}
+
+	aggregated, err := r.aggregateRecommendations(instance)
+	if err != nil {
+		klog.Error("Failed to list placement recommendations with error: ", err)
+		return reconcile.Result{}, err
+	}
+
+	if err = r.continueDecisionMakingProcess(dm, instance, changed, aggregated); err != nil {
+		return reconcile.Result{}, err
+	}
+
+	// reconcile again when the next advisor times out
+	return reconcile.Result{RequeueAfter: nextDeadline(instance, time.Now())}, nil
 }
 
-func (r *ReconcilePlacementRule) resetDecisionMakingProcess(candidates []corev1.ObjectReference, instance *corev1alpha1.PlacementRule) error {
+func (r *ReconcilePlacementRule) resetDecisionMakingProcess(dm DecisionMaker, candidates []corev1.ObjectReference, summary *corev1alpha1.TargetSummary,
+	instance *corev1alpha1.PlacementRule) error {
 	instance.Status.ObservedGeneration = instance.GetGeneration()
 	now := metav1.Now()
 	instance.Status.LastUpdateTime = &now
 	instance.Status.Candidates = candidates
 	instance.Status.Recommendations = nil
+	instance.Status.RecommendationRounds = nil
+	instance.Status.AdvisorErrors = nil
 	instance.Status.Eliminators = nil
+	instance.Status.Eliminations = nil
+	instance.Status.Scores = nil
+	instance.Status.TargetSummary = nil
+	instance.Status.RelaxedAdvisors = nil
+	// the decision maker stages the advisors of the new round
+	instance.Status.Stage = corev1alpha1.AdvisorTypeUnknown
+	// recommendations of earlier rounds are stale
+	instance.Status.Round++
+	startRound(instance, now.Time)
 
-	r.decisionMaker.ResetDecisionMakingProcess(candidates, instance)
+	setTargetSummary(instance, summary)
+
+	dm.ResetDecisionMakingProcess(candidates, instance)
+
+	setCondition(instance, corev1alpha1.PlacementRuleConditionReady, metav1.ConditionFalse, corev1alpha1.PlacementRuleReasonDeciding,
+		"Decision making is restarted for the latest spec and candidates")
+	setAdvisorsPending(instance, now.Time)
+	setAdvisorStatuses(instance, nil, now.Time)
 
 	return r.client.Status().Update(context.TODO(), instance)
 }
 
-func (r *ReconcilePlacementRule) continueDecisionMakingProcess(instance *corev1alpha1.PlacementRule) error {
-	readytodecide := true
+// continueDecisionMakingProcess updates the status if decisions are made, or if the status is already changed by the caller,
+// aggregated recommendations of PlacementRecommendations and recommendations made for timed out advisors are not written
+// to the status
+func (r *ReconcilePlacementRule) continueDecisionMakingProcess(dm DecisionMaker, instance *corev1alpha1.PlacementRule, changed bool,
+	aggregated map[string]bool) error {
+	now := time.Now()
+	round := instance.Status.Round
 
-	for _, adv := range instance.Spec.Advisors {
-		if instance.Status.Recommendations == nil {
-			readytodecide = false
-			break
-		}
+	// rules decided before advisor timeouts start their current round now
+	if instance.Status.RoundStartTime == nil {
+		startRound(instance, now)
 
-		if _, ok := instance.Status.Recommendations[adv.Name]; !ok {
-			readytodecide = false
-			break
-		}
+		changed = true
+	}
+
+	if rememberRecommendations(instance) {
+		changed = true
+	}
+
+	applied := applyFailurePolicies(instance, now)
+
+	if len(pendingAdvisors(instance)) == 0 && dm.ContinueDecisionMakingProcess(instance) {
+		changed = true
+	}
+
+	// the decision maker may have started a new round of recommendations
+	if instance.Status.Round != round {
+		startRound(instance, now)
+		discardStaleRecommendations(instance)
+
+		applied = nil
+	}
+
+	if setAdvisorsPending(instance, now) {
+		changed = true
 	}
 
-	if readytodecide && r.decisionMaker.ContinueDecisionMakingProcess(instance) {
+	if setAdvisorStatuses(instance, applied, now) {
+		changed = true
+	}
+
+	if changed {
+		for advisor := range applied {
+			delete(instance.Status.Recommendations, advisor)
+		}
+
+		removeAggregatedRecommendations(instance, aggregated)
+
 		return r.client.Status().Update(context.TODO(), instance)
 	}
 
@@ -16,21 +16,35 @@ package placementrule
 
 import (
 	"context"
+	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	. "github.com/onsi/gomega"
 
+	"github.com/hybridapp-io/ham-placement/pkg/advisor"
+	"github.com/hybridapp-io/ham-placement/pkg/advisor/alphabet"
+	"github.com/hybridapp-io/ham-placement/pkg/advisor/framework"
+	labeladvisor "github.com/hybridapp-io/ham-placement/pkg/advisor/labels"
+	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
+	"github.com/hybridapp-io/ham-placement/pkg/advisor/veto"
 	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apiextensions-apiserver/pkg/apis/apiextensions"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 
 	managedclusterv1 "github.com/open-cluster-management/api/cluster/v1"
 	"k8s.io/klog"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -1038,6 +1052,11 @@ func TestScoredAndUnscoredRecommendations(t *testing.T) {
 		}
 	}()
 
+	// new clusters are picked up by the target watch, wait for the rule to settle
+	WaitForSettledReconcile(requests)
+	g.Expect(c.Get(context.TODO(), prKey, pr)).NotTo(HaveOccurred())
+	g.Expect(len(pr.Status.Candidates)).To(Equal(3))
+
 	// cluster3
 	rhacmRecommendations := []corev1alpha1.ScoredObjectReference{
 		{
@@ -1113,9 +1132,6 @@ func TestScoredAndUnscoredRecommendations(t *testing.T) {
 	// wait for reconciliation triggered by status update
 	g.Eventually(requests, timeout, interval).Should(Receive(Equal(expectedRequest)))
 
-	// wait for reconciliation triggered by status update
-	g.Eventually(requests, timeout, interval).Should(Receive(Equal(expectedRequest)))
-
 	// simulate the advisor reconciliation, as the recommendations have been cleaned up during hpr reconciliation
 	g.Expect(c.Get(context.TODO(), prKey, pr)).NotTo(HaveOccurred())
 	pr.Status.Recommendations = map[string]corev1alpha1.Recommendation{
@@ -1311,4 +1327,3083 @@ func TestScoredAndUnscoredRecommendations(t *testing.T) {
 	g.Expect(len(pr.Status.Decisions)).To(Equal(1))
 	g.Expect(pr.Status.Decisions[0].Name).To(Equal(cl3.Name))
 
+	// cluster2 is vetoed by grc first, cluster1 is eliminated in a later round by its lower total
+	g.Expect(pr.Status.Eliminations).To(HaveLen(2))
+	g.Expect(pr.Status.Eliminations[0].Name).To(Equal(cl2.Name))
+	g.Expect(pr.Status.Eliminations[0].VetoedBy).To(Equal(advisor3.Name))
+	g.Expect(pr.Status.Eliminations[1].Name).To(Equal(cl1.Name))
+	g.Expect(pr.Status.Eliminations[1].VetoedBy).To(BeEmpty())
+	g.Expect(pr.Status.Eliminations[1].Round).To(BeNumerically(">", pr.Status.Eliminations[0].Round))
+	g.Expect(pr.Status.Eliminations[1].Decision).To(Equal(int64(corev1alpha1.DefaultDecisionWeight * corev1alpha1.ScorePrecision)))
+	g.Expect(pr.Status.Eliminations[1].Advisors).To(HaveLen(1))
+	g.Expect(pr.Status.Eliminations[1].Advisors[0].Advisor).To(Equal(advisor2.Name))
+	g.Expect(pr.Status.Eliminations[1].Advisors[0].Weight).To(Equal(newCostWeight))
+
+	// the decision is scored with rhacm and its decision weight
+	g.Expect(pr.Status.Scores).To(HaveLen(1))
+	g.Expect(pr.Status.Scores[0].Name).To(Equal(cl3.Name))
+	g.Expect(pr.Status.Scores[0].Advisors).To(ConsistOf(corev1alpha1.AdvisorScore{
+		Advisor: advisor1.Name,
+		Score:   corev1alpha1.DefaultScore,
+		Weight:  newRHACMWeight,
+		Value:   int64(newRHACMWeight) * corev1alpha1.ScorePrecision,
+	}))
+	g.Expect(pr.Status.Scores[0].Total).To(Equal((int64(newRHACMWeight) + corev1alpha1.DefaultDecisionWeight) * corev1alpha1.ScorePrecision))
+	g.Expect(pr.Status.Scores[0].Total).To(BeNumerically(">", pr.Status.Eliminations[1].Total))
+	g.Expect(pr.Status.Eliminations[1].Total).To(Equal(int64(170 * corev1alpha1.ScorePrecision)))
+}
+
+func TestPartialScores(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- 2 managed clusters, placement rule with 1 replica, no decision weight and the cost priority advisor
+	- cost scores cluster1 40 and cluster2 60: both would count as 0 without fractional scores, cluster2 wins
+	- cost gets the score range 0-10 and scores cluster1 7 and cluster2 3: cluster1 wins
+	**/
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	decisionWeight := int16(0)
+	pr.Spec.Replicas = &replica
+	pr.Spec.DecisionWeight = &decisionWeight
+	pr.Spec.Targets = []corev1.ObjectReference{{Name: cl1.Name}, {Name: cl2.Name}}
+	pr.Spec.Advisors = []corev1alpha1.Advisor{*costAdvisor.DeepCopy()}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	// simulate the cost advisor on every round until the rule is decided
+	decide := func(scores map[string]int16) func() []string {
+		return func() []string {
+			if err := c.Get(context.TODO(), prKey, hpr); err != nil || hpr.Status.ObservedGeneration != hpr.GetGeneration() {
+				return nil
+			}
+
+			if _, ok := hpr.Status.Recommendations[costAdvisor.Name]; !ok {
+				var rec corev1alpha1.Recommendation
+
+				for _, or := range hpr.Status.Candidates {
+					score := scores[or.Name]
+					rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or, Score: &score})
+				}
+
+				hpr.Status.Recommendations = map[string]corev1alpha1.Recommendation{costAdvisor.Name: rec}
+
+				if err := c.Status().Update(context.TODO(), hpr); err != nil {
+					return nil
+				}
+			}
+
+			cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionReady)
+			if cond == nil || cond.Status != metav1.ConditionTrue {
+				return nil
+			}
+
+			var names []string
+			for _, or := range hpr.Status.Decisions {
+				names = append(names, or.Name)
+			}
+
+			return names
+		}
+	}
+
+	g.Eventually(decide(map[string]int16{cl1.Name: 40, cl2.Name: 60}), timeout, interval).Should(ConsistOf(cl2.Name))
+
+	g.Expect(hpr.Status.Eliminations).To(HaveLen(1))
+	g.Expect(hpr.Status.Eliminations[0].Name).To(Equal(cl1.Name))
+	g.Expect(hpr.Status.Eliminations[0].Total).To(Equal(int64(CostPriority) * 40 * corev1alpha1.ScorePrecision / 100))
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		hpr.Spec.Advisors[0].ScoreRange = &corev1alpha1.ScoreRange{Min: 0, Max: 10}
+
+		return c.Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(decide(map[string]int16{cl1.Name: 7, cl2.Name: 3}), timeout, interval).Should(ConsistOf(cl1.Name))
+
+	g.Expect(hpr.Status.Eliminations).To(HaveLen(1))
+	g.Expect(hpr.Status.Eliminations[0].Name).To(Equal(cl2.Name))
+	g.Expect(hpr.Status.Eliminations[0].Total).To(Equal(int64(CostPriority) * 3 * corev1alpha1.ScorePrecision / 10))
+}
+
+func TestTargetChanges(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- placement rule selecting clusters by label, no replicas
+	- expected decisions follow the clusters:
+		cl1 labeled: cl1
+		cl2 labeled and created: cl1, cl2
+		cl1 relabeled: cl2
+		cl2 deleted: none
+	**/
+
+	labelsMap := map[string]string{"test_label": "test"}
+
+	cl1 := mc1.DeepCopy()
+	cl1.Labels = labelsMap
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	pr.Spec.TargetLabels = &metav1.LabelSelector{
+		MatchLabels: labelsMap,
+	}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	decisions := func() []string {
+		hpr := &corev1alpha1.PlacementRule{}
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return nil
+		}
+
+		var names []string
+		for _, or := range hpr.Status.Decisions {
+			names = append(names, or.Name)
+		}
+
+		return names
+	}
+
+	g.Eventually(decisions, timeout, interval).Should(ConsistOf(cl1.Name))
+
+	cl2 := mc2.DeepCopy()
+	cl2.Labels = labelsMap
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	g.Eventually(decisions, timeout, interval).Should(ConsistOf(cl1.Name, cl2.Name))
+
+	g.Expect(c.Get(context.TODO(), mc1Key, cl1)).NotTo(HaveOccurred())
+	cl1.Labels = nil
+	g.Expect(c.Update(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	g.Eventually(decisions, timeout, interval).Should(ConsistOf(cl2.Name))
+
+	g.Expect(c.Delete(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	g.Eventually(decisions, timeout, interval).Should(BeEmpty())
+}
+
+func TestDeployerChanges(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- placement rule for the ibminfra deployer type, created before the deployer
+	- expected decisions follow the deployer:
+		no deployer: none
+		deployer created: the deployer itself
+		deployer targets managed clusters: cl1
+	**/
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	pr.Spec.DeployerType = &ibminfraDeployerName
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	decisions := func() []string {
+		hpr := &corev1alpha1.PlacementRule{}
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return nil
+		}
+
+		var names []string
+		for _, or := range hpr.Status.Decisions {
+			names = append(names, or.Name)
+		}
+
+		return names
+	}
+
+	g.Consistently(decisions, 3*interval, interval).Should(BeEmpty())
+
+	deployer := ibminfraDeployer.DeepCopy()
+	defer func() {
+		if err = c.Delete(context.TODO(), deployer); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+	g.Expect(c.Create(context.TODO(), deployer)).To(Succeed())
+
+	g.Eventually(decisions, timeout, interval).Should(ConsistOf(deployer.Name))
+
+	g.Expect(c.Get(context.TODO(), ibminfraDeployerKey, deployer)).NotTo(HaveOccurred())
+	deployer.Spec.PlacementTarget = corev1alpha1.DefaultKubernetesPlacementTarget.DeepCopy()
+	g.Expect(c.Update(context.TODO(), deployer)).To(Succeed())
+
+	g.Eventually(decisions, timeout, interval).Should(ConsistOf(cl1.Name))
+}
+
+func TestDegradedKeepsDecisions(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- placement rule for the ibminfra deployer type, decided on the deployer itself
+	- the deployer is deleted: decisions are kept and the rule is degraded
+	- the deployer is recreated: the rule is not degraded anymore
+	**/
+
+	deployer := ibminfraDeployer.DeepCopy()
+	g.Expect(c.Create(context.TODO(), deployer)).To(Succeed())
+
+	pr := placementRule.DeepCopy()
+	pr.Spec.DeployerType = &ibminfraDeployerName
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	degraded := func() metav1.ConditionStatus {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return metav1.ConditionUnknown
+		}
+
+		cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionDegraded)
+		if cond == nil {
+			return metav1.ConditionUnknown
+		}
+
+		return cond.Status
+	}
+
+	g.Eventually(degraded, timeout, interval).Should(Equal(metav1.ConditionFalse))
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	decisions := hpr.Status.Decisions
+
+	g.Expect(c.Delete(context.TODO(), deployer)).To(Succeed())
+
+	g.Eventually(degraded, timeout, interval).Should(Equal(metav1.ConditionTrue))
+	g.Expect(meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionDegraded).Reason).
+		To(Equal(corev1alpha1.PlacementRuleReasonTargetNotFound))
+	g.Expect(hpr.Status.Decisions).To(Equal(decisions))
+
+	deployer = ibminfraDeployer.DeepCopy()
+	defer func() {
+		if err = c.Delete(context.TODO(), deployer); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+	g.Expect(c.Create(context.TODO(), deployer)).To(Succeed())
+
+	g.Eventually(degraded, timeout, interval).Should(Equal(metav1.ConditionFalse))
+}
+
+func TestConditions(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- placement rule with 2 replicas and the grc predicate advisor, one managed cluster
+	- no grc recommendation: advisors pending, not ready
+	- grc recommends the cluster: unsatisfiable with 1 of 2 replicas, not ready
+	- replicas lowered to 1: ready
+	**/
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replicas := int16(2)
+	pr.Spec.Replicas = &replicas
+	pr.Spec.Advisors = []corev1alpha1.Advisor{grcAdvisor}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	condition := func(ctype string) func() string {
+		return func() string {
+			if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+				return ""
+			}
+
+			cond := meta.FindStatusCondition(hpr.Status.Conditions, ctype)
+			if cond == nil || cond.ObservedGeneration != hpr.GetGeneration() {
+				return ""
+			}
+
+			return string(cond.Status) + "/" + cond.Reason
+		}
+	}
+
+	g.Eventually(condition(corev1alpha1.PlacementRuleConditionAdvisorsPending), timeout, interval).
+		Should(Equal("True/" + corev1alpha1.PlacementRuleReasonWaitingForAdvisors))
+	g.Expect(condition(corev1alpha1.PlacementRuleConditionReady)()).
+		To(Equal("False/" + corev1alpha1.PlacementRuleReasonWaitingForAdvisors))
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		hpr.Status.Recommendations = map[string]corev1alpha1.Recommendation{
+			grcAdvisor.Name: {{ObjectReference: hpr.Status.Candidates[0]}},
+		}
+
+		return c.Status().Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(condition(corev1alpha1.PlacementRuleConditionUnsatisfiable), timeout, interval).
+		Should(Equal("True/" + corev1alpha1.PlacementRuleReasonInsufficientCandidates))
+	g.Expect(condition(corev1alpha1.PlacementRuleConditionAdvisorsPending)()).
+		To(Equal("False/" + corev1alpha1.PlacementRuleReasonAdvisorsRecommended))
+	g.Expect(condition(corev1alpha1.PlacementRuleConditionReady)()).
+		To(Equal("False/" + corev1alpha1.PlacementRuleReasonInsufficientCandidates))
+	g.Expect(hpr.Status.Decisions).To(HaveLen(1))
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		replicas = int16(1)
+		hpr.Spec.Replicas = &replicas
+
+		return c.Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	// the spec change restarts the decision making process, grc is asked again
+	g.Eventually(condition(corev1alpha1.PlacementRuleConditionAdvisorsPending), timeout, interval).
+		Should(Equal("True/" + corev1alpha1.PlacementRuleReasonWaitingForAdvisors))
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		hpr.Status.Recommendations = map[string]corev1alpha1.Recommendation{
+			grcAdvisor.Name: {{ObjectReference: hpr.Status.Candidates[0]}},
+		}
+
+		return c.Status().Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(condition(corev1alpha1.PlacementRuleConditionReady), timeout, interval).
+		Should(Equal("True/" + corev1alpha1.PlacementRuleReasonDecided))
+	g.Expect(condition(corev1alpha1.PlacementRuleConditionUnsatisfiable)()).
+		To(Equal("False/" + corev1alpha1.PlacementRuleReasonEnoughCandidates))
+}
+
+func TestTargetSummary(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- 3 managed clusters, cl1 and cl2 labeled
+	- placement rule selecting labeled clusters, 2 replicas, grc predicate advisor
+	- grc recommends cl1 only: 1 decision, 1 vetoed by grc and 1 filtered by targetLabels
+	**/
+
+	labelsMap := map[string]string{"test_label": "test"}
+
+	cl1 := mc1.DeepCopy()
+	cl1.Labels = labelsMap
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	cl2.Labels = labelsMap
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl3 := mc3.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl3)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl3); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replicas := int16(2)
+	pr.Spec.Replicas = &replicas
+	pr.Spec.TargetLabels = &metav1.LabelSelector{
+		MatchLabels: labelsMap,
+	}
+	pr.Spec.Advisors = []corev1alpha1.Advisor{grcAdvisor}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() int {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return len(hpr.Status.Candidates)
+	}, timeout, interval).Should(Equal(2))
+
+	g.Expect(hpr.Status.TargetSummary).NotTo(BeNil())
+	g.Expect(hpr.Status.TargetSummary.Eligible).To(Equal(int32(2)))
+	g.Expect(hpr.Status.TargetSummary.Message).To(ContainSubstring("1 filtered by targetLabels"))
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		for _, or := range hpr.Status.Candidates {
+			if or.Name == cl1.Name {
+				hpr.Status.Recommendations = map[string]corev1alpha1.Recommendation{
+					grcAdvisor.Name: {{ObjectReference: or}},
+				}
+			}
+		}
+
+		return c.Status().Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.TargetSummary.Eligible).To(Equal(int32(1)))
+	g.Expect(hpr.Status.TargetSummary.Message).To(ContainSubstring("1 vetoed by grc"))
+	g.Expect(hpr.Status.TargetSummary.Message).To(ContainSubstring("1 filtered by targetLabels"))
+
+	cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionUnsatisfiable)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Message).To(ContainSubstring(hpr.Status.TargetSummary.Message))
+}
+
+func TestTieBreak(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- 3 managed clusters, placement rule with 1 replica, no decision weight and no advisors: every candidate ties
+	- default tie-break by name keeps cl1, cl3 is eliminated first
+	- tie-break by hash keeps the lowest hash of the rule and the cluster uid
+	**/
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc3, mc1, mc2} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	decisionWeight := int16(0)
+	pr.Spec.Replicas = &replica
+	pr.Spec.DecisionWeight = &decisionWeight
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	decided := func() []string {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil || hpr.Status.ObservedGeneration != hpr.GetGeneration() {
+			return nil
+		}
+
+		cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionReady)
+		if cond == nil || cond.Status != metav1.ConditionTrue {
+			return nil
+		}
+
+		var names []string
+		for _, or := range hpr.Status.Decisions {
+			names = append(names, or.Name)
+		}
+
+		return names
+	}
+
+	g.Eventually(decided, timeout, interval).Should(ConsistOf(mc1Name))
+
+	g.Expect(hpr.Status.Eliminations).To(HaveLen(2))
+	g.Expect(hpr.Status.Eliminations[0].Name).To(Equal(mc3Name))
+	g.Expect(hpr.Status.Eliminations[1].Name).To(Equal(mc2Name))
+
+	expected := clusters[0]
+	for _, cl := range clusters[1:] {
+		if hash(prNamespace+"/"+prName+"/"+string(cl.UID)) < hash(prNamespace+"/"+prName+"/"+string(expected.UID)) {
+			expected = cl
+		}
+	}
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		policy := corev1alpha1.TieBreakByHash
+		hpr.Spec.TieBreak = &policy
+
+		return c.Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(decided, timeout, interval).Should(ConsistOf(expected.Name))
+	g.Consistently(decided, 3*interval, interval).Should(ConsistOf(expected.Name))
+}
+
+func TestSinglePass(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- 3 managed clusters, single pass placement rule with 1 replica, grc predicate and cost priority advisors
+	- grc vetoes cl3, cost scores cl1 30 and cl2 80
+	- cl2 is decided on the first recommendations, cl1 and cl3 are eliminated in the same round
+	**/
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2, mc3} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	mode := corev1alpha1.DecisionModeSinglePass
+	pr.Spec.Replicas = &replica
+	pr.Spec.DecisionMode = &mode
+	pr.Spec.Advisors = []corev1alpha1.Advisor{*grcAdvisor.DeepCopy(), *costAdvisor.DeepCopy()}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() int {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return len(hpr.Status.Candidates)
+	}, timeout, interval).Should(Equal(3))
+
+	round := hpr.Status.Round
+	scores := map[string]int16{mc1Name: 30, mc2Name: 80}
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		var grc, cost corev1alpha1.Recommendation
+
+		for _, or := range hpr.Status.Candidates {
+			if or.Name == mc3Name {
+				continue
+			}
+
+			score := scores[or.Name]
+			grc = append(grc, corev1alpha1.ScoredObjectReference{ObjectReference: or})
+			cost = append(cost, corev1alpha1.ScoredObjectReference{ObjectReference: or, Score: &score})
+		}
+
+		hpr.Status.Recommendations = map[string]corev1alpha1.Recommendation{grcAdvisor.Name: grc, costAdvisor.Name: cost}
+
+		return c.Status().Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc2Name))
+	g.Expect(hpr.Status.Round).To(Equal(round))
+	g.Expect(hpr.Status.Recommendations).To(HaveLen(2))
+
+	g.Expect(hpr.Status.Eliminations).To(HaveLen(2))
+	g.Expect(hpr.Status.Eliminations[0].Name).To(Equal(mc3Name))
+	g.Expect(hpr.Status.Eliminations[0].VetoedBy).To(Equal(grcAdvisor.Name))
+	g.Expect(hpr.Status.Eliminations[1].Name).To(Equal(mc1Name))
+
+	cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionReady)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+}
+
+func TestEliminationStep(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- 3 managed clusters, placement rule with 1 replica, no decision weight and no advisors
+	- a step of 100% of the candidates eliminates cl3 and cl2 in the same round, never below the replicas
+	**/
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2, mc3} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	decisionWeight := int16(0)
+	size := intstr.FromString("100%")
+	pr.Spec.Replicas = &replica
+	pr.Spec.DecisionWeight = &decisionWeight
+	pr.Spec.Step = &corev1alpha1.EliminationStep{Size: &size}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc1Name))
+	g.Expect(hpr.Status.Eliminations).To(HaveLen(2))
+	g.Expect(hpr.Status.Eliminations[0].Name).To(Equal(mc3Name))
+	g.Expect(hpr.Status.Eliminations[1].Name).To(Equal(mc2Name))
+	g.Expect(hpr.Status.Eliminations[0].Round).To(Equal(hpr.Status.Eliminations[1].Round))
+}
+
+func TestPendingPriorityAdvisor(t *testing.T) {
+	g := NewWithT(t)
+
+	/**
+	- 3 candidates, placement rule with 1 replica and the cost and rhacm priority advisors
+	- cost recommends, rhacm has not reported yet: no candidate is eliminated and the round goes on
+	- rhacm recommends: candidates are eliminated in a new round
+	**/
+
+	var candidates []corev1.ObjectReference
+	for _, name := range []string{mc1Name, mc2Name, mc3Name} {
+		candidates = append(candidates, corev1.ObjectReference{Name: name, UID: types.UID(name)})
+	}
+
+	scored := func(scores ...int16) corev1alpha1.Recommendation {
+		var rec corev1alpha1.Recommendation
+
+		for i, or := range candidates {
+			score := scores[i]
+			rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or, Score: &score})
+		}
+
+		return rec
+	}
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{*costAdvisor.DeepCopy(), *rhacmAdvisor.DeepCopy()}
+	pr.Status.Round = 1
+	pr.Status.Candidates = candidates
+	pr.Status.Recommendations = map[string]corev1alpha1.Recommendation{costAdvisor.Name: scored(100, 50, 0)}
+
+	dm := &DefaultDecisionMaker{}
+
+	dm.ContinueDecisionMakingProcess(pr)
+
+	g.Expect(pr.Status.Round).To(Equal(int64(1)))
+	g.Expect(pr.Status.Candidates).To(HaveLen(3))
+	g.Expect(pr.Status.Eliminations).To(BeEmpty())
+	g.Expect(pr.Status.Recommendations).To(HaveKey(costAdvisor.Name))
+
+	pr.Status.Recommendations[rhacmAdvisor.Name] = scored(100, 50, 0)
+
+	g.Expect(dm.ContinueDecisionMakingProcess(pr)).To(BeTrue())
+
+	g.Expect(pr.Status.Round).To(Equal(int64(2)))
+	g.Expect(pr.Status.Candidates).To(HaveLen(2))
+	g.Expect(pr.Status.Eliminations).To(HaveLen(1))
+	g.Expect(pr.Status.Eliminations[0].Name).To(Equal(mc3Name))
+	g.Expect(pr.Status.Recommendations).To(BeEmpty())
+}
+
+// unregisterDecisionMaker removes a decision maker registered by a test from the registry
+func unregisterDecisionMaker(name string) {
+	decisionMakersLock.Lock()
+	defer decisionMakersLock.Unlock()
+
+	delete(decisionMakers, name)
+}
+
+// firstDecisionMaker decides the first candidates up to the rule replicas
+type firstDecisionMaker struct{}
+
+func (d *firstDecisionMaker) ResetDecisionMakingProcess(candidates []corev1.ObjectReference, instance *corev1alpha1.PlacementRule) {
+	instance.Status.Candidates = candidates
+}
+
+func (d *firstDecisionMaker) ContinueDecisionMakingProcess(instance *corev1alpha1.PlacementRule) bool {
+	decisions := instance.Status.Candidates
+	if instance.Spec.Replicas != nil && len(decisions) > int(*instance.Spec.Replicas) {
+		decisions = decisions[:*instance.Spec.Replicas]
+	}
+
+	if len(decisions) == len(instance.Status.Decisions) {
+		return false
+	}
+
+	instance.Status.Decisions = decisions
+	setDecided(instance)
+
+	return true
+}
+
+func TestDecisionMakerRegistry(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	g.Expect(RegisterDecisionMaker("first", &firstDecisionMaker{})).To(Succeed())
+	defer unregisterDecisionMaker("first")
+	g.Expect(RegisterDecisionMaker("first", &firstDecisionMaker{})).NotTo(Succeed())
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(NewReconciler(mgr, Options{}).decisionMakers).To(HaveKey("first"))
+
+	// Options.DecisionMaker takes precedence over the default entry of Options.DecisionMakers
+	defaultDM, overrideDM := &DefaultDecisionMaker{}, &DefaultDecisionMaker{}
+	rec := NewReconciler(mgr, Options{
+		DecisionMaker:  overrideDM,
+		DecisionMakers: map[string]DecisionMaker{DefaultDecisionMakerName: defaultDM},
+	})
+	g.Expect(rec.decisionMakers[DefaultDecisionMakerName]).To(BeIdenticalTo(overrideDM))
+	rec = NewReconciler(mgr, Options{DecisionMakers: map[string]DecisionMaker{DefaultDecisionMakerName: defaultDM}})
+	g.Expect(rec.decisionMakers[DefaultDecisionMakerName]).To(BeIdenticalTo(defaultDM))
+
+	// the engine decides with its own decision makers instead of the registered ones
+	dms := map[string]DecisionMaker{"first": &firstDecisionMaker{}}
+	g.Expect(AddWithOptions(mgr, Options{DecisionMakers: dms})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- 2 managed clusters, placement rule with 1 replica and the unregistered decision maker "unknown"
+	- the rule is not ready with reason UnknownDecisionMaker
+	- the rule selects the registered decision maker "first", which decides the first candidate
+	**/
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	unknown := "unknown"
+	pr.Spec.Replicas = &replica
+	pr.Spec.DecisionMaker = &unknown
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() string {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+
+		cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionReady)
+		if cond == nil {
+			return ""
+		}
+
+		return cond.Reason
+	}, timeout, interval).Should(Equal(corev1alpha1.PlacementRuleReasonUnknownDecisionMaker))
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		first := "first"
+		hpr.Spec.DecisionMaker = &first
+
+		return c.Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(hpr.Status.Candidates[0].Name))
+}
+
+func TestEmbeddedEngine(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 2 managed clusters, an engine ignoring cl2 with its own alphabet advisor registry
+	- placement rule with the alphabet advisor: cl1 is decided by the advisor of the engine, cl2 is ignored
+	**/
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(alphabet.AdvisorName, alphabet.Add)).To(Succeed())
+	g.Expect(advisors.Register(alphabet.AdvisorName, alphabet.Add)).NotTo(Succeed())
+
+	ignored := []corev1.ObjectReference{{
+		Name:       mc2Name,
+		Kind:       corev1alpha1.DefaultKubernetesPlacementTargetGVK.Kind,
+		APIVersion: corev1alpha1.DefaultKubernetesPlacementTarget.Group + "/" + corev1alpha1.DefaultKubernetesPlacementTarget.Version,
+	}}
+
+	g.Expect(AddWithOptions(mgr, Options{Name: "embedded-placement", Advisors: advisors, IgnoredTargets: ignored})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	pr.Spec.Advisors = []corev1alpha1.Advisor{{Name: alphabet.AdvisorName}}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc1Name))
+	g.Expect(placementRecommendation(g, c, hpr, alphabet.AdvisorName).Spec.Error).To(BeEmpty())
+	g.Expect(hpr.Status.TargetSummary.Message).To(ContainSubstring("1 ignored (" + mc2Name + ")"))
+}
+
+func TestIndependentEngines(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 2 managed clusters, 2 engines in the same manager selecting rules by the engine label
+	- engine a ignores cl2, engine b ignores cl1
+	- the rule of each engine is decided on the targets it does not ignore only
+	**/
+
+	ignored := func(name string) []corev1.ObjectReference {
+		return []corev1.ObjectReference{{
+			Name:       name,
+			Kind:       corev1alpha1.DefaultKubernetesPlacementTargetGVK.Kind,
+			APIVersion: corev1alpha1.DefaultKubernetesPlacementTarget.Group + "/" + corev1alpha1.DefaultKubernetesPlacementTarget.Version,
+		}}
+	}
+
+	g.Expect(AddWithOptions(mgr, Options{Name: "engine-a", IgnoredTargets: ignored(mc2Name),
+		RuleSelector: labels.SelectorFromSet(labels.Set{"engine": "a"})})).To(Succeed())
+	g.Expect(AddWithOptions(mgr, Options{Name: "engine-b", IgnoredTargets: ignored(mc1Name),
+		RuleSelector: labels.SelectorFromSet(labels.Set{"engine": "b"})})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pra := placementRule.DeepCopy()
+	pra.Labels = map[string]string{"engine": "a"}
+	defer func() {
+		if err = c.Delete(context.TODO(), pra); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pra)).To(Succeed())
+
+	prb := placementRule.DeepCopy()
+	prb.Name = prName + "-b"
+	prb.Labels = map[string]string{"engine": "b"}
+	defer func() {
+		if err = c.Delete(context.TODO(), prb); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), prb)).To(Succeed())
+
+	decisions := func(key types.NamespacedName) func() []corev1.ObjectReference {
+		return func() []corev1.ObjectReference {
+			hpr := &corev1alpha1.PlacementRule{}
+			g.Expect(c.Get(context.TODO(), key, hpr)).To(Succeed())
+
+			return hpr.Status.Decisions
+		}
+	}
+
+	prbKey := types.NamespacedName{Name: prb.Name, Namespace: prb.Namespace}
+
+	g.Eventually(decisions(prKey), timeout, interval).Should(HaveLen(1))
+	g.Eventually(decisions(prbKey), timeout, interval).Should(HaveLen(1))
+
+	g.Consistently(func() string {
+		return decisions(prKey)()[0].Name + "," + decisions(prbKey)()[0].Name
+	}, 3*interval, interval).Should(Equal(mc1Name + "," + mc2Name))
+}
+
+// ruleRecorder recommends every candidate as a priority advisor and remembers the rules it advised
+type ruleRecorder struct {
+	mu    sync.Mutex
+	rules map[string]bool
+}
+
+func (r *ruleRecorder) Name() string {
+	return "recorder"
+}
+
+func (r *ruleRecorder) Type() corev1alpha1.AdvisorType {
+	return corev1alpha1.AdvisorTypePriority
+}
+
+func (r *ruleRecorder) Recommend(instance *corev1alpha1.PlacementRule,
+	_ *corev1alpha1.Advisor) ([]corev1alpha1.ScoredObjectReference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.rules[instance.Name] = true
+
+	var rec []corev1alpha1.ScoredObjectReference
+
+	for _, or := range instance.Status.Candidates {
+		rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or})
+	}
+
+	return rec, nil
+}
+
+func (r *ruleRecorder) advised() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var names []string
+	for name := range r.rules {
+		names = append(names, name)
+	}
+
+	return names
+}
+
+func TestIndependentEngineAdvisors(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 2 managed clusters, 2 engines in the same manager selecting rules by the engine label
+	- both engines register their own instance of the recorder advisor
+	- each rule is decided with the recorder of its engine, no recorder advises the rule of the other engine
+	**/
+
+	recorders := map[string]*ruleRecorder{}
+
+	for _, engine := range []string{"a", "b"} {
+		recorder := &ruleRecorder{rules: make(map[string]bool)}
+		recorders[engine] = recorder
+
+		advisors := advisor.NewRegistry()
+		g.Expect(advisors.Register(recorder.Name(), framework.New(recorder).Add)).To(Succeed())
+
+		g.Expect(AddWithOptions(mgr, Options{Name: "engine-" + engine, Advisors: advisors,
+			RuleSelector: labels.SelectorFromSet(labels.Set{"engine": engine})})).To(Succeed())
+	}
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	replica := int16(defaultReplicas)
+	keys := map[string]types.NamespacedName{}
+
+	for _, engine := range []string{"a", "b"} {
+		pr := placementRule.DeepCopy()
+		pr.Name = prName + "-" + engine
+		pr.Labels = map[string]string{"engine": engine}
+		pr.Spec.Replicas = &replica
+		pr.Spec.Advisors = []corev1alpha1.Advisor{{Name: recorders[engine].Name()}}
+
+		g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+		defer func() {
+			if err = c.Delete(context.TODO(), pr); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}()
+
+		keys[engine] = types.NamespacedName{Name: pr.Name, Namespace: pr.Namespace}
+	}
+
+	for _, engine := range []string{"a", "b"} {
+		key := keys[engine]
+
+		g.Eventually(func() []corev1.ObjectReference {
+			hpr := &corev1alpha1.PlacementRule{}
+			g.Expect(c.Get(context.TODO(), key, hpr)).To(Succeed())
+
+			return hpr.Status.Decisions
+		}, timeout, interval).Should(HaveLen(1))
+	}
+
+	g.Consistently(recorders["a"].advised, 3*interval, interval).Should(Equal([]string{keys["a"].Name}))
+	g.Consistently(recorders["b"].advised, 3*interval, interval).Should(Equal([]string{keys["b"].Name}))
+}
+
+func TestConcurrentAdvisors(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 3 managed clusters, placement rule with 1 replica, veto predicate advisor vetoing cl1 and alphabet priority advisor
+	- both advisors patch their recommendations of every round without overwriting each other: cl2 is decided
+	**/
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(alphabet.AdvisorName, alphabet.Add)).To(Succeed())
+	g.Expect(advisors.Register(veto.AdvisorName, veto.Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2, mc3} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{
+		{
+			Name:  veto.AdvisorName,
+			Type:  &AdvisorTypePredicate,
+			Rules: &runtime.RawExtension{Raw: []byte(`{"resources":[{"name":"` + mc1Name + `"}]}`)},
+		},
+		{Name: alphabet.AdvisorName},
+	}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc2Name))
+	g.Expect(placementRecommendation(g, c, hpr, veto.AdvisorName).Spec.Error).To(BeEmpty())
+	g.Expect(placementRecommendation(g, c, hpr, alphabet.AdvisorName).Spec.Error).To(BeEmpty())
+}
+
+// exceptRecommender recommends every candidate except one, as a predicate advisor
+type exceptRecommender struct {
+	except string
+}
+
+func (r *exceptRecommender) Name() string {
+	return "except"
+}
+
+func (r *exceptRecommender) Type() corev1alpha1.AdvisorType {
+	return corev1alpha1.AdvisorTypePredicate
+}
+
+func (r *exceptRecommender) Recommend(instance *corev1alpha1.PlacementRule,
+	_ *corev1alpha1.Advisor) ([]corev1alpha1.ScoredObjectReference, error) {
+	var rec []corev1alpha1.ScoredObjectReference
+
+	for _, or := range instance.Status.Candidates {
+		if or.Name != r.except {
+			rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or})
+		}
+	}
+
+	return rec, nil
+}
+
+func TestPlacementRecommendations(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 2 managed clusters, placement rule with 1 replica and a predicate advisor excepting cl1
+	- the advisor creates a PlacementRecommendation instead of writing the rule status: cl2 is decided
+	- the recommendation is aggregated by the controller and not written to the rule status
+	**/
+
+	recommender := &exceptRecommender{except: mc1Name}
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(recommender.Name(), framework.New(recommender).Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{{Name: recommender.Name(), Type: &AdvisorTypePredicate}}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc2Name))
+	g.Expect(hpr.Status.Recommendations).NotTo(HaveKey(recommender.Name()))
+
+	recs := &corev1alpha1.PlacementRecommendationList{}
+	g.Expect(c.List(context.TODO(), recs, client.InNamespace(prNamespace),
+		client.MatchingLabels{corev1alpha1.LabelPlacementRule: prName, corev1alpha1.LabelAdvisor: recommender.Name()})).To(Succeed())
+	g.Expect(recs.Items).To(HaveLen(1))
+	g.Expect(recs.Items[0].Name).To(Equal(advisorutils.RecommendationName(prName, recommender.Name())))
+	g.Expect(advisorutils.RecommendationName("a-b", "c")).NotTo(Equal(advisorutils.RecommendationName("a", "b-c")))
+	g.Expect(recs.Items[0].Spec.Round).To(Equal(hpr.Status.Round))
+	g.Expect(recs.Items[0].OwnerReferences).To(HaveLen(1))
+
+	g.Expect(c.Delete(context.TODO(), &recs.Items[0])).To(Succeed())
+}
+
+func TestLongRuleNameRecommendations(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 2 managed clusters, placement rule with a name longer than a label value and 1 replica
+	- the recorder advisor writes PlacementRecommendations as an instance id with spaces and slashes
+	- the recommendation is created with hashed labels, aggregated by rule, and decides the rule
+	**/
+
+	recorder := &ruleRecorder{rules: make(map[string]bool)}
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(recorder.Name(), framework.New(recorder).Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	pr.Name = prName + "-" + strings.Repeat("long", 40)
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{{Name: recorder.Name(), ID: "Recorder of gold/silver clusters"}}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	key := types.NamespacedName{Name: pr.Name, Namespace: pr.Namespace}
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), key, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	prec := &corev1alpha1.PlacementRecommendation{}
+	g.Expect(c.Get(context.TODO(), types.NamespacedName{Namespace: pr.Namespace,
+		Name: advisorutils.RecommendationName(pr.Name, pr.Spec.Advisors[0].InstanceID())}, prec)).To(Succeed())
+	g.Expect(prec.Spec.PlacementRef.Name).To(Equal(pr.Name))
+	g.Expect(prec.Labels).To(HaveKeyWithValue(corev1alpha1.LabelPlacementRule, advisorutils.LabelValue(pr.Name)))
+	g.Expect(prec.Labels[corev1alpha1.LabelPlacementRule]).NotTo(Equal(pr.Name))
+}
+
+func TestStaleRecommendations(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- 2 managed clusters, placement rule with 1 replica and the cost priority advisor
+	- a cost recommendation stamped with an earlier round is discarded
+	- a cost recommendation stamped with the current round decides the rule
+	**/
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{*costAdvisor.DeepCopy()}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() int {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return len(hpr.Status.Candidates)
+	}, timeout, interval).Should(Equal(2))
+
+	round := hpr.Status.Round
+
+	recommend := func(round int64) func() error {
+		return func() error {
+			if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+				return err
+			}
+
+			var rec corev1alpha1.Recommendation
+
+			for _, or := range hpr.Status.Candidates {
+				if or.Name == mc1Name {
+					rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or})
+				}
+			}
+
+			hpr.Status.Recommendations = map[string]corev1alpha1.Recommendation{costAdvisor.Name: rec}
+			hpr.Status.RecommendationRounds = map[string]int64{costAdvisor.Name: round}
+
+			return c.Status().Update(context.TODO(), hpr)
+		}
+	}
+
+	g.Eventually(recommend(round-1), timeout, interval).Should(Succeed())
+
+	g.Eventually(func() bool {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return meta.IsStatusConditionTrue(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionAdvisorsPending) &&
+			hpr.Status.RecommendationRounds == nil
+	}, timeout, interval).Should(BeTrue())
+
+	g.Expect(hpr.Status.Recommendations).NotTo(HaveKey(costAdvisor.Name))
+	g.Expect(hpr.Status.Decisions).To(BeEmpty())
+
+	g.Eventually(recommend(round), timeout, interval).Should(Succeed())
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc1Name))
+}
+
+func TestConcurrentFirstRecommendations(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- 2 managed clusters, placement rule with 1 replica and the cost and rhacm priority advisors
+	- both advisors patch the first recommendation of the round at the same time, from the same snapshot
+	- both recommendations are kept and decide the rule
+	**/
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{*costAdvisor.DeepCopy(), *rhacmAdvisor.DeepCopy()}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() bool {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return len(hpr.Status.Candidates) == 2 &&
+			meta.IsStatusConditionTrue(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionAdvisorsPending)
+	}, timeout, interval).Should(BeTrue())
+
+	g.Expect(hpr.Status.Recommendations).To(BeEmpty())
+
+	var rec []corev1alpha1.ScoredObjectReference
+
+	for _, or := range hpr.Status.Candidates {
+		if or.Name == mc1Name {
+			rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or})
+		}
+	}
+
+	var wg sync.WaitGroup
+
+	errs := make(chan error, 2)
+
+	for _, id := range []string{costAdvisor.Name, rhacmAdvisor.Name} {
+		patch, err := advisorutils.RecommendationPatch(hpr, id, rec)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		wg.Add(1)
+
+		go func(patch client.Patch) {
+			defer wg.Done()
+			errs <- c.Status().Patch(context.TODO(), hpr.DeepCopy(), patch)
+		}(patch)
+	}
+
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc1Name))
+	g.Expect(hpr.Status.Recommendations).To(HaveKey(costAdvisor.Name))
+	g.Expect(hpr.Status.Recommendations).To(HaveKey(rhacmAdvisor.Name))
+}
+
+func TestAdvisorTimeouts(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	g.Expect(add(mgr, newReconciler(mgr))).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- 2 managed clusters, placement rule with 1 replica, the grc predicate and cost priority advisors with timeouts
+	- grc fails the rule by default: decisions are held once it times out
+	- grc uses its last known recommendation and cost is ignored: grc recommends both clusters in the first round,
+	  cost times out and one cluster is eliminated, both time out in the second round and the rule is decided
+	**/
+
+	cl1 := mc1.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	grc := grcAdvisor.DeepCopy()
+	grc.Timeout = &metav1.Duration{Duration: 5 * time.Second}
+
+	ignore := corev1alpha1.AdvisorFailurePolicyIgnore
+	cost := costAdvisor.DeepCopy()
+	cost.Timeout = &metav1.Duration{Duration: 2 * time.Second}
+	cost.FailurePolicy = &ignore
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{*grc, *cost}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	ready := func() string {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return ""
+		}
+
+		cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionReady)
+		if cond == nil || cond.ObservedGeneration != hpr.GetGeneration() {
+			return ""
+		}
+
+		return string(cond.Status) + "/" + cond.Reason
+	}
+
+	g.Eventually(ready, timeout, interval).Should(Equal("False/" + corev1alpha1.PlacementRuleReasonAdvisorsTimedOut))
+	g.Expect(hpr.Status.Decisions).To(BeEmpty())
+	g.Expect(hpr.Status.Advisors).To(HaveLen(2))
+	g.Expect(hpr.Status.Advisors[0].State).To(Equal(corev1alpha1.AdvisorStateTimedOut))
+	g.Expect(hpr.Status.Advisors[0].FailurePolicy).To(Equal(corev1alpha1.AdvisorFailurePolicyFail))
+	g.Expect(hpr.Status.Advisors[1].State).To(Equal(corev1alpha1.AdvisorStateTimedOut))
+	g.Expect(hpr.Status.Advisors[1].FailurePolicy).To(Equal(corev1alpha1.AdvisorFailurePolicyIgnore))
+
+	// the spec change starts a new round
+	useLastKnown := corev1alpha1.AdvisorFailurePolicyUseLastKnown
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		hpr.Spec.Advisors[0].FailurePolicy = &useLastKnown
+
+		return c.Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(func() bool {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.ObservedGeneration == hpr.GetGeneration()
+	}, timeout, interval).Should(BeTrue())
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		var rec corev1alpha1.Recommendation
+		for _, or := range hpr.Status.Candidates {
+			rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or})
+		}
+
+		hpr.Status.Recommendations = map[string]corev1alpha1.Recommendation{grc.Name: rec}
+
+		return c.Status().Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(ready, timeout, interval).Should(Equal("True/" + corev1alpha1.PlacementRuleReasonDecided))
+	g.Expect(hpr.Status.Decisions).To(HaveLen(1))
+	g.Expect(hpr.Status.Eliminators).To(HaveLen(1))
+	g.Expect(hpr.Status.LastKnownRecommendations).To(HaveKey(grc.Name))
+	g.Expect(hpr.Status.Recommendations).To(BeEmpty())
+	g.Expect(hpr.Status.Advisors[0].State).To(Equal(corev1alpha1.AdvisorStateTimedOut))
+	g.Expect(hpr.Status.Advisors[0].FailurePolicy).To(Equal(corev1alpha1.AdvisorFailurePolicyUseLastKnown))
+	g.Expect(hpr.Status.Advisors[1].FailurePolicy).To(Equal(corev1alpha1.AdvisorFailurePolicyIgnore))
+}
+
+func TestAdvisorErrors(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 2 managed clusters, placement rule with 1 replica, veto predicate advisor with misspelled rules and alphabet priority advisor
+	- veto reports the parse error instead of recommending every candidate: decisions are held
+	- the rules are fixed to veto cl1: cl2 is decided
+	**/
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(alphabet.AdvisorName, alphabet.Add)).To(Succeed())
+	g.Expect(advisors.Register(veto.AdvisorName, veto.Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{
+		{
+			Name:  veto.AdvisorName,
+			Type:  &AdvisorTypePredicate,
+			Rules: &runtime.RawExtension{Raw: []byte(`{"resource":[{"name":"` + mc1Name + `"}]}`)},
+		},
+		{Name: alphabet.AdvisorName},
+	}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() string {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+
+		cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionReady)
+		if cond == nil {
+			return ""
+		}
+
+		return cond.Reason
+	}, timeout, interval).Should(Equal(corev1alpha1.PlacementRuleReasonAdvisorsFailed))
+
+	g.Expect(hpr.Status.Decisions).To(BeEmpty())
+	g.Expect(placementRecommendation(g, c, hpr, veto.AdvisorName).Spec.Error).To(ContainSubstring("unknown field"))
+	g.Expect(hpr.Status.Advisors).To(HaveLen(2))
+	g.Expect(hpr.Status.Advisors[0].State).To(Equal(corev1alpha1.AdvisorStateFailed))
+	g.Expect(hpr.Status.Advisors[0].Error).To(ContainSubstring("unknown field"))
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		hpr.Spec.Advisors[0].Rules = &runtime.RawExtension{Raw: []byte(`{"resources":[{"name":"` + mc1Name + `"}]}`)}
+
+		return c.Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc2Name))
+	g.Expect(hpr.Status.AdvisorErrors).To(BeEmpty())
+	g.Expect(placementRecommendation(g, c, hpr, veto.AdvisorName).Spec.Error).To(BeEmpty())
+}
+
+func TestAdvisorInstances(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 3 managed clusters, placement rule with 1 replica and two instances of the veto advisor,
+	  compliance vetoing cl1 and maintenance vetoing cl2: cl3 is decided
+	- both instances with the same id: the rule is invalid and its decisions are kept
+	**/
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(veto.AdvisorName, veto.Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2, mc3} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{
+		{
+			Name:  veto.AdvisorName,
+			ID:    "compliance",
+			Type:  &AdvisorTypePredicate,
+			Rules: &runtime.RawExtension{Raw: []byte(`{"resources":[{"name":"` + mc1Name + `"}]}`)},
+		},
+		{
+			Name:  veto.AdvisorName,
+			ID:    "maintenance",
+			Type:  &AdvisorTypePredicate,
+			Rules: &runtime.RawExtension{Raw: []byte(`{"resources":[{"name":"` + mc2Name + `"}]}`)},
+		},
+	}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc3Name))
+	g.Expect(placementRecommendation(g, c, hpr, "compliance").Spec.Error).To(BeEmpty())
+	g.Expect(placementRecommendation(g, c, hpr, "maintenance").Spec.Error).To(BeEmpty())
+	g.Expect(hpr.Status.Advisors).To(HaveLen(2))
+	g.Expect(hpr.Status.Advisors[0].ID).To(Equal("compliance"))
+	g.Expect(hpr.Status.Advisors[1].ID).To(Equal("maintenance"))
+
+	vetoedBy := make(map[string]string)
+	for _, cs := range hpr.Status.Eliminations {
+		vetoedBy[cs.Name] = cs.VetoedBy
+	}
+
+	g.Expect(vetoedBy).To(Equal(map[string]string{mc1Name: "compliance", mc2Name: "maintenance"}))
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		hpr.Spec.Advisors[1].ID = "Compliance"
+
+		return c.Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(func() string {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+
+		cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionReady)
+		if cond == nil {
+			return ""
+		}
+
+		return cond.Reason
+	}, timeout, interval).Should(Equal(corev1alpha1.PlacementRuleReasonInvalidAdvisors))
+
+	g.Expect(hpr.Status.Decisions).To(HaveLen(1))
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc3Name))
+}
+
+func TestEmptyRecommendations(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 2 managed clusters, placement rule with 1 replica, veto predicate advisor vetoing both clusters
+	- veto recommends nothing with an empty recommendation: no candidate is decided
+	**/
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(veto.AdvisorName, veto.Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{
+		{
+			Name: veto.AdvisorName,
+			Type: &AdvisorTypePredicate,
+			Rules: &runtime.RawExtension{
+				Raw: []byte(`{"resources":[{"name":"` + mc1Name + `"},{"name":"` + mc2Name + `"}]}`),
+			},
+		},
+	}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() string {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+
+		cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionUnsatisfiable)
+		if cond == nil {
+			return ""
+		}
+
+		return cond.Reason
+	}, timeout, interval).Should(Equal(corev1alpha1.PlacementRuleReasonNoCandidates))
+
+	g.Expect(hpr.Status.Decisions).To(BeEmpty())
+	g.Expect(placementRecommendation(g, c, hpr, veto.AdvisorName).Spec.Recommendation).To(BeEmpty())
+	g.Expect(placementRecommendation(g, c, hpr, veto.AdvisorName).Spec.Error).To(BeEmpty())
+}
+
+// scoredRecommender recommends every candidate as a priority advisor and remembers the candidates it scored
+type scoredRecommender struct {
+	mu     sync.Mutex
+	scored map[string]bool
+}
+
+func (r *scoredRecommender) Name() string {
+	return "scored"
+}
+
+func (r *scoredRecommender) Type() corev1alpha1.AdvisorType {
+	return corev1alpha1.AdvisorTypePriority
+}
+
+func (r *scoredRecommender) Recommend(instance *corev1alpha1.PlacementRule,
+	_ *corev1alpha1.Advisor) ([]corev1alpha1.ScoredObjectReference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var rec []corev1alpha1.ScoredObjectReference
+
+	for _, or := range instance.Status.Candidates {
+		r.scored[or.Name] = true
+		rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or})
+	}
+
+	return rec, nil
+}
+
+func (r *scoredRecommender) hasScored(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.scored[name]
+}
+
+func TestStagedPipeline(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 3 managed clusters, staged placement rule with 1 replica, veto predicate advisor vetoing cl1 and a priority advisor
+	- veto recommends first, cl1 is eliminated before the priority advisor scores cl2 and cl3: cl2 is decided
+	- the priority advisor never scores cl1
+	**/
+
+	recommender := &scoredRecommender{scored: make(map[string]bool)}
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(veto.AdvisorName, veto.Add)).To(Succeed())
+	g.Expect(advisors.Register(recommender.Name(), framework.New(recommender).Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2, mc3} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pipeline := corev1alpha1.AdvisorPipelineStaged
+	pr.Spec.Replicas = &replica
+	pr.Spec.Pipeline = &pipeline
+	pr.Spec.Advisors = []corev1alpha1.Advisor{
+		{
+			Name:  veto.AdvisorName,
+			Type:  &AdvisorTypePredicate,
+			Rules: &runtime.RawExtension{Raw: []byte(`{"resources":[{"name":"` + mc1Name + `"}]}`)},
+		},
+		{Name: recommender.Name()},
+	}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc2Name))
+	g.Expect(hpr.Status.Stage).To(Equal(corev1alpha1.AdvisorTypePriority))
+	g.Expect(hpr.Status.Eliminations).To(HaveLen(2))
+	g.Expect(hpr.Status.Eliminations[0].Name).To(Equal(mc1Name))
+	g.Expect(hpr.Status.Eliminations[0].VetoedBy).To(Equal(veto.AdvisorName))
+	g.Expect(hpr.Status.Advisors).To(HaveLen(2))
+	g.Expect(hpr.Status.Advisors[0].State).To(Equal(corev1alpha1.AdvisorStateIdle))
+	g.Expect(hpr.Status.Advisors[1].State).To(Equal(corev1alpha1.AdvisorStateRecommended))
+
+	g.Expect(recommender.hasScored(mc2Name)).To(BeTrue())
+	g.Expect(recommender.hasScored(mc3Name)).To(BeTrue())
+	g.Expect(recommender.hasScored(mc1Name)).To(BeFalse())
+}
+
+func TestPreferredPredicates(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 3 managed clusters, placement rule with 2 replicas and 3 veto instances
+	- compliance vetoes cl1, preferred maintenance vetoes cl2, preferred capacity with a lower weight vetoes cl3
+	- no cluster passes every predicate: capacity is relaxed first, then maintenance, cl2 and cl3 are decided
+	**/
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(veto.AdvisorName, veto.Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2, mc3} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replicas := int16(2)
+	capacityWeight := int16(10)
+	pr.Spec.Replicas = &replicas
+	pr.Spec.Advisors = []corev1alpha1.Advisor{
+		{
+			Name:  veto.AdvisorName,
+			ID:    "compliance",
+			Type:  &AdvisorTypePredicate,
+			Rules: &runtime.RawExtension{Raw: []byte(`{"resources":[{"name":"` + mc1Name + `"}]}`)},
+		},
+		{
+			Name:      veto.AdvisorName,
+			ID:        "maintenance",
+			Type:      &AdvisorTypePredicate,
+			Preferred: true,
+			Rules:     &runtime.RawExtension{Raw: []byte(`{"resources":[{"name":"` + mc2Name + `"}]}`)},
+		},
+		{
+			Name:      veto.AdvisorName,
+			ID:        "capacity",
+			Type:      &AdvisorTypePredicate,
+			Weight:    &capacityWeight,
+			Preferred: true,
+			Rules:     &runtime.RawExtension{Raw: []byte(`{"resources":[{"name":"` + mc3Name + `"}]}`)},
+		},
+	}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(2))
+
+	var decided []string
+	for _, or := range hpr.Status.Decisions {
+		decided = append(decided, or.Name)
+	}
+
+	g.Expect(decided).To(ConsistOf(mc2Name, mc3Name))
+	g.Expect(hpr.Status.RelaxedAdvisors).To(Equal([]string{"capacity", "maintenance"}))
+
+	cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionReady)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+
+	// without replicas the rule needs one candidate: only capacity is relaxed, cl3 is decided
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		hpr.Spec.Replicas = nil
+
+		return c.Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(func() []string {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+
+		if hpr.Status.ObservedGeneration != hpr.GetGeneration() {
+			return nil
+		}
+
+		var decided []string
+		for _, or := range hpr.Status.Decisions {
+			decided = append(decided, or.Name)
+		}
+
+		return decided
+	}, timeout, interval).Should(Equal([]string{mc3Name}))
+
+	g.Expect(hpr.Status.RelaxedAdvisors).To(Equal([]string{"capacity"}))
+}
+
+func TestFallback(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 3 managed clusters, placement rule with 1 replica targeting cl1 and cl2, cl3 as fallback, veto predicate advisor
+	- veto vetoes cl1 and cl2: no candidate is left, the rule is placed on cl3 and the fallback is active
+	- veto rules are changed to veto cl1 only: cl2 is decided and the fallback is inactive
+	**/
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(veto.AdvisorName, veto.Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2, mc3} {
+		cl := mc.DeepCopy()
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Targets = []corev1.ObjectReference{{Name: mc1Name}, {Name: mc2Name}}
+	pr.Spec.Fallback = &corev1alpha1.Fallback{Targets: []corev1.ObjectReference{{Name: mc3Name}}}
+	pr.Spec.Advisors = []corev1alpha1.Advisor{
+		{
+			Name: veto.AdvisorName,
+			Type: &AdvisorTypePredicate,
+			Rules: &runtime.RawExtension{
+				Raw: []byte(`{"resources":[{"name":"` + mc1Name + `"},{"name":"` + mc2Name + `"}]}`),
+			},
+		},
+	}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc3Name))
+	g.Expect(hpr.Status.FallbackTargets).To(HaveLen(1))
+	g.Expect(hpr.Status.FallbackTargets[0].Name).To(Equal(mc3Name))
+
+	cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionFallbackActive)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+
+	cond = meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionReady)
+	g.Expect(cond).NotTo(BeNil())
+	g.Expect(cond.Status).To(Equal(metav1.ConditionTrue))
+	g.Expect(cond.Reason).To(Equal(corev1alpha1.PlacementRuleReasonFallbackTargets))
+
+	g.Eventually(func() error {
+		if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+			return err
+		}
+
+		hpr.Spec.Advisors[0].Rules = &runtime.RawExtension{Raw: []byte(`{"resources":[{"name":"` + mc1Name + `"}]}`)}
+
+		return c.Update(context.TODO(), hpr)
+	}, timeout, interval).Should(Succeed())
+
+	g.Eventually(func() string {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+
+		cond := meta.FindStatusCondition(hpr.Status.Conditions, corev1alpha1.PlacementRuleConditionFallbackActive)
+		if cond == nil {
+			return ""
+		}
+
+		return cond.Reason
+	}, timeout, interval).Should(Equal(corev1alpha1.PlacementRuleReasonCandidatesDecided))
+
+	g.Expect(hpr.Status.Decisions).To(HaveLen(1))
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc2Name))
+}
+
+func TestLabelsAdvisor(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	/**
+	- 3 managed clusters, cl2 in region us-east, cl3 in tier gold, placement rule with 1 replica and labels priority advisor
+	- labels prefers tier=gold with weight 100 and region in (us-east,us-west) with weight 60: cl1 scores 0, cl2 37, cl3 62
+	- cl1 and cl2 are eliminated, cl3 is decided
+	**/
+
+	advisors := advisor.NewRegistry()
+	g.Expect(advisors.Register(labeladvisor.AdvisorName, labeladvisor.Add)).To(Succeed())
+
+	g.Expect(AddWithOptions(mgr, Options{Advisors: advisors})).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	clusterLabels := map[string]map[string]string{
+		mc2Name: {"region": "us-east"},
+		mc3Name: {"tier": "gold"},
+	}
+
+	var clusters []*managedclusterv1.ManagedCluster
+
+	for _, mc := range []*managedclusterv1.ManagedCluster{mc1, mc2, mc3} {
+		cl := mc.DeepCopy()
+		cl.Labels = clusterLabels[cl.Name]
+		g.Expect(c.Create(context.TODO(), cl)).NotTo(HaveOccurred())
+
+		clusters = append(clusters, cl)
+	}
+
+	defer func() {
+		for _, cl := range clusters {
+			if err = c.Delete(context.TODO(), cl); err != nil {
+				klog.Error(err)
+				t.Fail()
+			}
+		}
+	}()
+
+	pr := placementRule.DeepCopy()
+	replica := int16(defaultReplicas)
+	pr.Spec.Replicas = &replica
+	pr.Spec.Advisors = []corev1alpha1.Advisor{
+		{
+			Name: labeladvisor.AdvisorName,
+			Type: &AdvisorTypePriority,
+			Rules: &runtime.RawExtension{
+				Raw: []byte(`{"preferences":["tier=gold:100","region in (us-east,us-west):60"]}`),
+			},
+		},
+	}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Eventually(func() []corev1.ObjectReference {
+		g.Expect(c.Get(context.TODO(), prKey, hpr)).To(Succeed())
+		return hpr.Status.Decisions
+	}, timeout, interval).Should(HaveLen(1))
+
+	g.Expect(hpr.Status.Decisions[0].Name).To(Equal(mc3Name))
+	g.Expect(hpr.Status.Eliminations).To(HaveLen(2))
+	g.Expect(hpr.Status.Eliminations[0].Name).To(Equal(mc1Name))
+	g.Expect(hpr.Status.Eliminations[1].Name).To(Equal(mc2Name))
+	g.Expect(hpr.Status.Eliminations[1].Advisors).To(HaveLen(1))
+	g.Expect(hpr.Status.Eliminations[1].Advisors[0].Score).To(Equal(int16(37)))
+}
+
+// placementRecommendation returns the PlacementRecommendation of an advisor instance for the rule
+func placementRecommendation(g *WithT, c client.Client, instance *corev1alpha1.PlacementRule,
+	advisorID string) *corev1alpha1.PlacementRecommendation {
+	prec := &corev1alpha1.PlacementRecommendation{}
+	key := types.NamespacedName{Namespace: instance.Namespace, Name: advisorutils.RecommendationName(instance.Name, advisorID)}
+
+	g.Expect(c.Get(context.TODO(), key, prec)).To(Succeed())
+
+	return prec
+}
+
+// countingRecommender recommends every candidate as a priority advisor, or fails with its error, and counts its calls
+type countingRecommender struct {
+	mu    sync.Mutex
+	err   error
+	calls int
+}
+
+func (r *countingRecommender) Name() string {
+	return "counting"
+}
+
+func (r *countingRecommender) Type() corev1alpha1.AdvisorType {
+	return corev1alpha1.AdvisorTypePriority
+}
+
+func (r *countingRecommender) Recommend(instance *corev1alpha1.PlacementRule,
+	_ *corev1alpha1.Advisor) ([]corev1alpha1.ScoredObjectReference, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls++
+
+	if r.err != nil {
+		return nil, r.err
+	}
+
+	var rec []corev1alpha1.ScoredObjectReference
+
+	for _, or := range instance.Status.Candidates {
+		rec = append(rec, corev1alpha1.ScoredObjectReference{ObjectReference: or})
+	}
+
+	return rec, nil
+}
+
+func (r *countingRecommender) fail(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.err = err
+}
+
+func (r *countingRecommender) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.calls
+}
+
+// recommendationsTotal returns the recommendations counted by the advisor framework metrics for an advisor and result
+func recommendationsTotal(g *WithT, advisor, result string) float64 {
+	families, err := metrics.Registry.Gather()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	for _, mf := range families {
+		if mf.GetName() != "placement_advisor_recommendations_total" {
+			continue
+		}
+
+		for _, m := range mf.GetMetric() {
+			values := make(map[string]string)
+			for _, l := range m.GetLabel() {
+				values[l.GetName()] = l.GetValue()
+			}
+
+			if values["advisor"] == advisor && values["result"] == result {
+				return m.GetCounter().GetValue()
+			}
+		}
+	}
+
+	return 0
+}
+
+func TestAdvisorFramework(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	// the advisor runs without the placement rule controller, the test writes the rule status
+	recommender := &countingRecommender{}
+	g.Expect(framework.New(recommender).Add(mgr)).To(Succeed())
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	/**
+	- placement rule with the counting priority advisor declared as a predicate advisor
+	- the advisor skips the rule until its status observes the latest spec
+	- the advisor recommends for the current round despite the type mismatch, the recommendation is counted in metrics
+	- the advisor fails in the next round: the error is reported once for the round, retries do not update it again
+	**/
+
+	pr := placementRule.DeepCopy()
+	pr.Spec.Advisors = []corev1alpha1.Advisor{{Name: recommender.Name(), Type: &AdvisorTypePredicate}}
+	defer func() {
+		if err = c.Delete(context.TODO(), pr); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	g.Expect(c.Create(context.TODO(), pr)).To(Succeed())
+
+	hpr := &corev1alpha1.PlacementRule{}
+
+	g.Consistently(func() int {
+		return recommender.count()
+	}, 3*interval, interval).Should(BeZero())
+
+	recommended := recommendationsTotal(g, recommender.Name(), "recommended")
+	failed := recommendationsTotal(g, recommender.Name(), "error")
+
+	startRound := func(round int64) func() error {
+		return func() error {
+			if err := c.Get(context.TODO(), prKey, hpr); err != nil {
+				return err
+			}
+
+			hpr.Status.ObservedGeneration = hpr.GetGeneration()
+			hpr.Status.Round = round
+			hpr.Status.Candidates = []corev1.ObjectReference{{Name: mc1Name}}
+			hpr.Status.Recommendations = nil
+			hpr.Status.RecommendationRounds = nil
+			hpr.Status.AdvisorErrors = nil
+
+			return c.Status().Update(context.TODO(), hpr)
+		}
+	}
+
+	g.Eventually(startRound(1), timeout, interval).Should(Succeed())
+
+	prec := &corev1alpha1.PlacementRecommendation{}
+	precKey := types.NamespacedName{Namespace: prNamespace, Name: advisorutils.RecommendationName(prName, recommender.Name())}
+
+	recommendation := func() corev1alpha1.PlacementRecommendationSpec {
+		if err := c.Get(context.TODO(), precKey, prec); err != nil {
+			return corev1alpha1.PlacementRecommendationSpec{}
+		}
+
+		return prec.Spec
+	}
+
+	g.Eventually(func() int64 {
+		return recommendation().Round
+	}, timeout, interval).Should(Equal(int64(1)))
+
+	g.Expect(prec.Spec.Recommendation).To(HaveLen(1))
+	g.Expect(prec.Spec.Error).To(BeEmpty())
+	g.Expect(recommendationsTotal(g, recommender.Name(), "recommended")).To(BeNumerically(">", recommended))
+
+	recommender.fail(fmt.Errorf("advisor unavailable"))
+
+	g.Eventually(startRound(2), timeout, interval).Should(Succeed())
+
+	g.Eventually(func() string {
+		if spec := recommendation(); spec.Round == 2 {
+			return spec.Error
+		}
+
+		return ""
+	}, timeout, interval).Should(Equal("advisor unavailable"))
+
+	g.Expect(prec.Spec.Recommendation).To(BeEmpty())
+	g.Expect(recommendationsTotal(g, recommender.Name(), "error")).To(BeNumerically(">", failed))
+
+	resourceVersion := prec.ResourceVersion
+	calls := recommender.count()
+
+	g.Eventually(func() int {
+		return recommender.count()
+	}, timeout, interval/10).Should(BeNumerically(">", calls))
+
+	g.Consistently(func() string {
+		g.Expect(c.Get(context.TODO(), precKey, prec)).To(Succeed())
+		return prec.ResourceVersion
+	}, 3*interval, interval).Should(Equal(resourceVersion))
+}
+
+func TestTargetCache(t *testing.T) {
+	g := NewWithT(t)
+
+	var c client.Client
+
+	mgr, err := manager.New(cfg, manager.Options{MetricsBindAddress: "0"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	c = mgr.GetClient()
+
+	stopMgr, mgrStopped := StartTestManager(mgr, g)
+
+	defer func() {
+		close(stopMgr)
+		mgrStopped.Wait()
+	}()
+
+	labelsMap := map[string]string{"test_label": "test"}
+
+	cl1 := mc1.DeepCopy()
+	cl1.Labels = labelsMap
+	g.Expect(c.Create(context.TODO(), cl1)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl1); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	cl2 := mc2.DeepCopy()
+	g.Expect(c.Create(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	defer func() {
+		if err = c.Delete(context.TODO(), cl2); err != nil {
+			klog.Error(err)
+			t.Fail()
+		}
+	}()
+
+	tc := &targetCache{cache: mgr.GetCache(), mapper: mgr.GetRESTMapper()}
+	gvr := convertMetaGVRToScheme(corev1alpha1.DefaultKubernetesPlacementTarget)
+
+	targets := func(selector labels.Selector) []string {
+		tl, err := tc.list(*gvr, selector)
+		g.Expect(err).NotTo(HaveOccurred())
+
+		var names []string
+		for _, obj := range tl.Items {
+			names = append(names, obj.GetName())
+		}
+
+		return names
+	}
+
+	// the informer is started and synced by the first read
+	g.Expect(targets(labels.Everything())).To(ConsistOf(cl1.Name, cl2.Name))
+	g.Expect(targets(labels.SelectorFromSet(labelsMap))).To(ConsistOf(cl1.Name))
+
+	// later changes are picked up by the informer
+	g.Expect(c.Get(context.TODO(), types.NamespacedName{Name: cl2.Name}, cl2)).NotTo(HaveOccurred())
+	cl2.Labels = labelsMap
+	g.Expect(c.Update(context.TODO(), cl2)).NotTo(HaveOccurred())
+
+	g.Eventually(func() []string { return targets(labels.SelectorFromSet(labelsMap)) }, timeout, interval).Should(ConsistOf(cl1.Name, cl2.Name))
 }
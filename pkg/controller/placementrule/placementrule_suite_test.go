@@ -19,6 +19,7 @@ import (
 	"path/filepath"
 	"sync"
 	"testing"
+	"time"
 
 	apis "github.com/hybridapp-io/ham-placement/pkg/apis"
 	"github.com/onsi/gomega"
@@ -75,8 +76,21 @@ func SetupTestReconcile(inner reconcile.Reconciler) (reconcile.Reconciler, chan
 	return fn, requests
 }
 
+// WaitForSettledReconcile drains the requests written by SetupTestReconcile until no reconciliation happens for a while
+func WaitForSettledReconcile(requests chan reconcile.Request) {
+	for {
+		select {
+		case <-requests:
+		case <-time.After(settleTimeout):
+			return
+		}
+	}
+}
+
 const waitgroupDelta = 1
 
+const settleTimeout = time.Second * 3
+
 // StartTestManager adds recFn
 func StartTestManager(mgr manager.Manager, g *gomega.GomegaWithT) (chan struct{}, *sync.WaitGroup) {
 	stop := make(chan struct{})
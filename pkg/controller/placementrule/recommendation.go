@@ -0,0 +1,200 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"context"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/klog"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// aggregateRecommendations adds the PlacementRecommendations of the current generation and round to the recommendations
+// or advisor errors of the rule, returns the advisors recommending through PlacementRecommendations.
+// Recommendations and errors written in the rule status take precedence.
+func (r *ReconcilePlacementRule) aggregateRecommendations(instance *corev1alpha1.PlacementRule) (map[string]bool, error) {
+	recs := &corev1alpha1.PlacementRecommendationList{}
+
+	// labels of recommendations are hints only, rule names and advisor instance ids might not be valid label values
+	err := r.client.List(context.TODO(), recs, client.InNamespace(instance.Namespace),
+		client.MatchingFields{recommendationRuleIndex: instance.Name})
+	if err != nil {
+		return nil, err
+	}
+
+	advisors := make(map[string]bool)
+	for _, adv := range instance.Spec.Advisors {
+		advisors[adv.InstanceID()] = true
+	}
+
+	aggregated := make(map[string]bool)
+
+	for _, rec := range recs.Items {
+		if rec.Spec.PlacementRef.Name != instance.Name || !isOwnedBy(&rec, instance) || !advisors[rec.Spec.Advisor] ||
+			rec.Spec.Generation != instance.Status.ObservedGeneration || rec.Spec.Round != instance.Status.Round {
+			continue
+		}
+
+		if _, ok := instance.Status.Recommendations[rec.Spec.Advisor]; ok {
+			continue
+		}
+
+		if _, ok := instance.Status.AdvisorErrors[rec.Spec.Advisor]; ok {
+			continue
+		}
+
+		if rec.Spec.Error != "" {
+			if instance.Status.AdvisorErrors == nil {
+				instance.Status.AdvisorErrors = make(map[string]corev1alpha1.AdvisorError)
+			}
+
+			instance.Status.AdvisorErrors[rec.Spec.Advisor] = corev1alpha1.AdvisorError{Round: rec.Spec.Round, Message: rec.Spec.Error}
+			aggregated[rec.Spec.Advisor] = true
+
+			continue
+		}
+
+		if instance.Status.Recommendations == nil {
+			instance.Status.Recommendations = make(map[string]corev1alpha1.Recommendation)
+		}
+
+		instance.Status.Recommendations[rec.Spec.Advisor] = withoutEmptyRecommendationReference(rec.Spec.Recommendation)
+		aggregated[rec.Spec.Advisor] = true
+	}
+
+	return aggregated, nil
+}
+
+// isOwnedBy returns true if the PlacementRecommendation is owned by the rule, not by a deleted rule of the same name
+func isOwnedBy(rec *corev1alpha1.PlacementRecommendation, instance *corev1alpha1.PlacementRule) bool {
+	for _, owner := range rec.OwnerReferences {
+		if owner.UID == instance.UID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// discardStaleRecommendations removes the recommendations stamped with another round than the current one, and the advisor
+// errors of other rounds or followed by a recommendation, returns true if a recommendation or an error is discarded.
+// Recommendations without stamp are kept for advisors not echoing the round.
+func discardStaleRecommendations(instance *corev1alpha1.PlacementRule) bool {
+	discarded := false
+
+	for advisor, aerr := range instance.Status.AdvisorErrors {
+		if _, ok := instance.Status.Recommendations[advisor]; !ok && aerr.Round == instance.Status.Round {
+			continue
+		}
+
+		delete(instance.Status.AdvisorErrors, advisor)
+
+		discarded = true
+	}
+
+	if len(instance.Status.AdvisorErrors) == 0 {
+		instance.Status.AdvisorErrors = nil
+	}
+
+	for advisor, round := range instance.Status.RecommendationRounds {
+		if _, ok := instance.Status.Recommendations[advisor]; ok && round == instance.Status.Round {
+			continue
+		}
+
+		klog.Info("Discarding recommendation of advisor ", advisor, " for round ", round, " of placement rule ",
+			instance.Namespace+"/"+instance.Name, " in round ", instance.Status.Round)
+
+		delete(instance.Status.Recommendations, advisor)
+		delete(instance.Status.RecommendationRounds, advisor)
+
+		discarded = true
+	}
+
+	if len(instance.Status.Recommendations) == 0 {
+		instance.Status.Recommendations = nil
+	}
+
+	if len(instance.Status.RecommendationRounds) == 0 {
+		instance.Status.RecommendationRounds = nil
+	}
+
+	return discarded
+}
+
+// removeEmptyRecommendationReferences removes the reference of the deprecated empty recommendation from the recommendations
+// of the rule, leaving empty recommendations. Returns true if a reference is removed.
+func removeEmptyRecommendationReferences(instance *corev1alpha1.PlacementRule) bool {
+	removed := false
+
+	for advisor, rec := range instance.Status.Recommendations {
+		nrec := withoutEmptyRecommendationReference(rec)
+		if len(nrec) != len(rec) {
+			instance.Status.Recommendations[advisor] = nrec
+			removed = true
+		}
+	}
+
+	return removed
+}
+
+// withoutEmptyRecommendationReference returns a copy of rec without the reference of the deprecated empty recommendation,
+// never nil
+func withoutEmptyRecommendationReference(rec corev1alpha1.Recommendation) corev1alpha1.Recommendation {
+	nrec := corev1alpha1.Recommendation{}
+
+	for _, or := range rec {
+		if !advisorutils.IsEmptyRecommendationReference(or.ObjectReference) {
+			nrec = append(nrec, *or.DeepCopy())
+		}
+	}
+
+	return nrec
+}
+
+// removeAggregatedRecommendations keeps the recommendations and errors of PlacementRecommendations out of the rule status
+func removeAggregatedRecommendations(instance *corev1alpha1.PlacementRule, aggregated map[string]bool) {
+	for advisor := range aggregated {
+		delete(instance.Status.Recommendations, advisor)
+		delete(instance.Status.AdvisorErrors, advisor)
+	}
+
+	if len(instance.Status.Recommendations) == 0 {
+		instance.Status.Recommendations = nil
+	}
+
+	if len(instance.Status.AdvisorErrors) == 0 {
+		instance.Status.AdvisorErrors = nil
+	}
+}
+
+// recommendationRuleMapper maps a PlacementRecommendation to its placement rule
+type recommendationRuleMapper struct{}
+
+var _ handler.Mapper = &recommendationRuleMapper{}
+
+func (m *recommendationRuleMapper) Map(obj handler.MapObject) []reconcile.Request {
+	rec, ok := obj.Object.(*corev1alpha1.PlacementRecommendation)
+	if !ok || rec.Spec.PlacementRef.Name == "" {
+		return nil
+	}
+
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: rec.Spec.PlacementRef.Name, Namespace: rec.Namespace}}}
+}
@@ -0,0 +1,94 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+const (
+	// DefaultDecisionMakerName is the decision maker of rules without spec.decisionMaker
+	DefaultDecisionMakerName = "default"
+)
+
+var (
+	decisionMakersLock sync.RWMutex
+	decisionMakers     = make(map[string]DecisionMaker)
+)
+
+// RegisterDecisionMaker registers a decision maker for the rules selecting its name in spec.decisionMaker.
+// Decision makers are registered before the controller is added to the manager,
+// registering DefaultDecisionMakerName replaces the iterative DefaultDecisionMaker.
+func RegisterDecisionMaker(name string, dm DecisionMaker) error {
+	if name == "" || dm == nil {
+		return fmt.Errorf("decision maker name and implementation are required")
+	}
+
+	decisionMakersLock.Lock()
+	defer decisionMakersLock.Unlock()
+
+	if _, ok := decisionMakers[name]; ok {
+		return fmt.Errorf("decision maker %s is already registered", name)
+	}
+
+	decisionMakers[name] = dm
+
+	return nil
+}
+
+// registeredDecisionMakers returns a copy of the registry
+func registeredDecisionMakers() map[string]DecisionMaker {
+	decisionMakersLock.RLock()
+	defer decisionMakersLock.RUnlock()
+
+	dms := make(map[string]DecisionMaker, len(decisionMakers))
+	for name, dm := range decisionMakers {
+		dms[name] = dm
+	}
+
+	return dms
+}
+
+// decisionMakerName returns the decision maker name selected by the rule
+func decisionMakerName(instance *corev1alpha1.PlacementRule) string {
+	if instance.Spec.DecisionMaker == nil || *instance.Spec.DecisionMaker == "" {
+		return DefaultDecisionMakerName
+	}
+
+	return *instance.Spec.DecisionMaker
+}
+
+// decisionMakerFor returns the decision maker selected by the rule, or an error listing the registered ones
+func (r *ReconcilePlacementRule) decisionMakerFor(instance *corev1alpha1.PlacementRule) (DecisionMaker, error) {
+	name := decisionMakerName(instance)
+
+	if dm, ok := r.decisionMakers[name]; ok {
+		return dm, nil
+	}
+
+	var names []string
+	for n := range r.decisionMakers {
+		names = append(names, n)
+	}
+
+	sort.Strings(names)
+
+	return nil, fmt.Errorf("decision maker %s is not registered, registered decision makers: %s", name, strings.Join(names, ", "))
+}
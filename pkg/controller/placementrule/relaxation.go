@@ -0,0 +1,118 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"reflect"
+	"sort"
+
+	"k8s.io/klog"
+
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// advisorWeight returns the weight of an advisor, the default weight if unset
+func advisorWeight(adv *corev1alpha1.Advisor) int16 {
+	if adv.Weight == nil {
+		return int16(corev1alpha1.DefaultAdvisorWeight)
+	}
+
+	return *adv.Weight
+}
+
+// preferredPredicates returns the preferred predicate advisors of the rule in relaxation order,
+// lowest weight first and in spec order for the same weight
+func preferredPredicates(instance *corev1alpha1.PlacementRule) []corev1alpha1.Advisor {
+	var preferred []corev1alpha1.Advisor
+
+	for _, adv := range instance.Spec.Advisors {
+		if adv.Preferred && adv.Type != nil && *adv.Type == corev1alpha1.AdvisorTypePredicate {
+			preferred = append(preferred, adv)
+		}
+	}
+
+	sort.SliceStable(preferred, func(i, j int) bool {
+		return advisorWeight(&preferred[i]) < advisorWeight(&preferred[j])
+	})
+
+	return preferred
+}
+
+// unrelaxedAdvisors returns the advisors of the rule except the relaxed preferred predicates
+func unrelaxedAdvisors(instance *corev1alpha1.PlacementRule) []corev1alpha1.Advisor {
+	return withoutAdvisors(instance.Spec.Advisors, instance.Status.RelaxedAdvisors)
+}
+
+// withoutAdvisors returns the advisors except the instance ids
+func withoutAdvisors(advisors []corev1alpha1.Advisor, ids []string) []corev1alpha1.Advisor {
+	if len(ids) == 0 {
+		return advisors
+	}
+
+	excluded := make(map[string]bool)
+	for _, id := range ids {
+		excluded[id] = true
+	}
+
+	var kept []corev1alpha1.Advisor
+
+	for _, adv := range advisors {
+		if !excluded[adv.InstanceID()] {
+			kept = append(kept, adv)
+		}
+	}
+
+	return kept
+}
+
+// relaxPredicates returns the preferred predicates to relax, in order, until enough candidates pass the predicates
+// for the replicas of the rule, nil if the candidates passing every predicate are enough.
+// Rules without replicas place on every candidate passing the predicates and need at least one.
+func (d *DefaultDecisionMaker) relaxPredicates(instance *corev1alpha1.PlacementRule) []string {
+	replicas := 1
+	if instance.Spec.Replicas != nil {
+		replicas = int(*instance.Spec.Replicas)
+	}
+
+	var relaxed []string
+
+	for _, adv := range preferredPredicates(instance) {
+		candidates := d.filterByAdvisorType(instance.Status.Candidates, withoutAdvisors(instance.Spec.Advisors, relaxed),
+			instance.Status.Recommendations, corev1alpha1.AdvisorTypePredicate)
+		if len(candidates) >= replicas {
+			break
+		}
+
+		relaxed = append(relaxed, adv.InstanceID())
+	}
+
+	return relaxed
+}
+
+// setRelaxedAdvisors records the preferred predicates relaxed for the current candidates, returns true if they are changed
+func setRelaxedAdvisors(instance *corev1alpha1.PlacementRule, relaxed []string) bool {
+	if (len(relaxed) == 0 && len(instance.Status.RelaxedAdvisors) == 0) || reflect.DeepEqual(relaxed, instance.Status.RelaxedAdvisors) {
+		return false
+	}
+
+	if len(relaxed) > 0 {
+		klog.Info("Relaxing preferred predicates ", relaxed, " of placement rule ", instance.Namespace+"/"+instance.Name,
+			", too few candidates pass them for the replicas")
+	}
+
+	instance.Status.RelaxedAdvisors = relaxed
+
+	return true
+}
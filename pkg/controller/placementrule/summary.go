@@ -0,0 +1,198 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// names of the spec filters in the target summary
+const (
+	filterIgnoredTargets = "ignoredTargets"
+	filterTargetLabels   = "targetLabels"
+	filterTargets        = "targets"
+	filterDeployerType   = "deployerType"
+)
+
+var targetFilterTypeOrder = map[corev1alpha1.TargetFilterType]int{
+	corev1alpha1.TargetFilterTypeVetoed:   0,
+	corev1alpha1.TargetFilterTypeFiltered: 1,
+	corev1alpha1.TargetFilterTypeIgnored:  2,
+}
+
+// addTargetFilter counts a target excluded by a spec filter in the summary
+func addTargetFilter(summary *corev1alpha1.TargetSummary, name, target string) {
+	ftype := corev1alpha1.TargetFilterTypeFiltered
+	if name == filterIgnoredTargets {
+		ftype = corev1alpha1.TargetFilterTypeIgnored
+	}
+
+	var filter *corev1alpha1.TargetFilter
+
+	for i := range summary.Filters {
+		if summary.Filters[i].Type == ftype && summary.Filters[i].Name == name {
+			filter = &summary.Filters[i]
+			break
+		}
+	}
+
+	if filter == nil {
+		summary.Filters = append(summary.Filters, corev1alpha1.TargetFilter{Type: ftype, Name: name})
+		filter = &summary.Filters[len(summary.Filters)-1]
+	}
+
+	filter.Count++
+
+	if ftype == corev1alpha1.TargetFilterTypeIgnored {
+		filter.Names = append(filter.Names, target)
+	}
+}
+
+// setTargetSummary replaces the spec filters of the target summary, vetoes of the current decision making process are kept
+func setTargetSummary(instance *corev1alpha1.PlacementRule, summary *corev1alpha1.TargetSummary) bool {
+	if summary == nil {
+		return false
+	}
+
+	nsum := summary.DeepCopy()
+
+	if instance.Status.TargetSummary != nil {
+		for _, filter := range instance.Status.TargetSummary.Filters {
+			if filter.Type == corev1alpha1.TargetFilterTypeVetoed {
+				nsum.Filters = append(nsum.Filters, filter)
+			}
+		}
+	}
+
+	return updateTargetSummary(instance, nsum)
+}
+
+// setVetoes sets the vetoed counts of predicate advisors to the candidates they veto in the current round,
+// on top of the candidates they eliminated in earlier rounds
+func setVetoes(instance *corev1alpha1.PlacementRule, vetoes map[string]int32) bool {
+	if instance.Status.TargetSummary == nil {
+		return false
+	}
+
+	nsum := instance.Status.TargetSummary.DeepCopy()
+	nsum.Filters = nil
+
+	for _, filter := range instance.Status.TargetSummary.Filters {
+		if filter.Type != corev1alpha1.TargetFilterTypeVetoed {
+			nsum.Filters = append(nsum.Filters, filter)
+			continue
+		}
+
+		filter.Count = filter.Eliminated + vetoes[filter.Name]
+		delete(vetoes, filter.Name)
+
+		if filter.Count > 0 {
+			nsum.Filters = append(nsum.Filters, filter)
+		}
+	}
+
+	for name, count := range vetoes {
+		if count > 0 {
+			nsum.Filters = append(nsum.Filters, corev1alpha1.TargetFilter{Type: corev1alpha1.TargetFilterTypeVetoed, Name: name, Count: count})
+		}
+	}
+
+	return updateTargetSummary(instance, nsum)
+}
+
+// eliminateVetoes records the candidates vetoed in the current round as eliminated
+func eliminateVetoes(instance *corev1alpha1.PlacementRule) {
+	if instance.Status.TargetSummary == nil {
+		return
+	}
+
+	for i := range instance.Status.TargetSummary.Filters {
+		filter := &instance.Status.TargetSummary.Filters[i]
+		if filter.Type == corev1alpha1.TargetFilterTypeVetoed {
+			filter.Eliminated = filter.Count
+		}
+	}
+}
+
+// updateTargetSummary sorts the filters, computes eligible targets and message, returns true if the summary is changed
+func updateTargetSummary(instance *corev1alpha1.PlacementRule, summary *corev1alpha1.TargetSummary) bool {
+	sort.SliceStable(summary.Filters, func(i, j int) bool {
+		fi, fj := summary.Filters[i], summary.Filters[j]
+		if fi.Type != fj.Type {
+			return targetFilterTypeOrder[fi.Type] < targetFilterTypeOrder[fj.Type]
+		}
+
+		return fi.Name < fj.Name
+	})
+
+	excluded := int32(0)
+
+	for i := range summary.Filters {
+		sort.Strings(summary.Filters[i].Names)
+		excluded += summary.Filters[i].Count
+	}
+
+	summary.Eligible = summary.Targets - excluded
+	if summary.Eligible < 0 {
+		summary.Eligible = 0
+	}
+
+	summary.Message = targetSummaryMessage(summary)
+
+	if reflect.DeepEqual(instance.Status.TargetSummary, summary) {
+		return false
+	}
+
+	instance.Status.TargetSummary = summary
+
+	return true
+}
+
+// targetSummaryMessage formats the summary like the kube-scheduler, largest filters first,
+// e.g. 0/12 targets eligible: 7 vetoed by veto, 3 filtered by targetLabels, 2 ignored (local-cluster)
+func targetSummaryMessage(summary *corev1alpha1.TargetSummary) string {
+	message := fmt.Sprintf("%d/%d targets eligible", summary.Eligible, summary.Targets)
+
+	filters := append([]corev1alpha1.TargetFilter{}, summary.Filters...)
+	sort.SliceStable(filters, func(i, j int) bool {
+		return filters[i].Count > filters[j].Count
+	})
+
+	var reasons []string
+
+	for _, filter := range filters {
+		if filter.Count == 0 {
+			continue
+		}
+
+		switch filter.Type {
+		case corev1alpha1.TargetFilterTypeIgnored:
+			reasons = append(reasons, fmt.Sprintf("%d ignored (%s)", filter.Count, strings.Join(filter.Names, ", ")))
+		default:
+			reasons = append(reasons, fmt.Sprintf("%d %s by %s", filter.Count, filter.Type, filter.Name))
+		}
+	}
+
+	if len(reasons) == 0 {
+		return message
+	}
+
+	return message + ": " + strings.Join(reasons, ", ")
+}
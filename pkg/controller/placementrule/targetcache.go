@@ -0,0 +1,67 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"context"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// targetCache reads placement targets by GroupVersionResource from the shared informers of the manager cache.
+// The informer of a target resource is started the first time it is read or watched, and is shared with the
+// target watch of the controller. Label selectors are evaluated on the cached objects.
+type targetCache struct {
+	cache  cache.Cache
+	mapper meta.RESTMapper
+}
+
+func (tc *targetCache) list(gvr schema.GroupVersionResource, selector labels.Selector) (*unstructured.UnstructuredList, error) {
+	gvk, err := tc.mapper.KindFor(gvr)
+	if err != nil {
+		return nil, err
+	}
+
+	tl := &unstructured.UnstructuredList{}
+	tl.SetGroupVersionKind(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+
+	err = tc.cache.List(context.TODO(), tl, client.MatchingLabelsSelector{Selector: selector})
+	if err != nil {
+		return nil, err
+	}
+
+	return tl, nil
+}
+
+// get reads the placement target of an object reference, the informer of its kind has to be started by list or watch
+func (tc *targetCache) get(or corev1.ObjectReference) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(schema.FromAPIVersionAndKind(or.APIVersion, or.Kind))
+
+	err := tc.cache.Get(context.TODO(), types.NamespacedName{Namespace: or.Namespace, Name: or.Name}, obj)
+	if err != nil {
+		return nil, err
+	}
+
+	return obj, nil
+}
@@ -0,0 +1,260 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"context"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"k8s.io/klog"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// targetWatcher starts a watch on a placement target resource the first time a PlacementRule refers to it.
+// Watches live as long as the controller, there is no way to stop a single source in controller-runtime.
+type targetWatcher struct {
+	controller controller.Controller
+	client     client.Client
+	mapper     meta.RESTMapper
+	ignored    []corev1.ObjectReference
+	rules      labels.Selector
+
+	mu      sync.Mutex
+	watched map[schema.GroupVersionResource]bool
+}
+
+func newTargetWatcher(c controller.Controller, cl client.Client, mapper meta.RESTMapper, ignored []corev1.ObjectReference,
+	rules labels.Selector) *targetWatcher {
+	return &targetWatcher{
+		controller: c,
+		client:     cl,
+		mapper:     mapper,
+		ignored:    ignored,
+		rules:      rules,
+		watched:    make(map[schema.GroupVersionResource]bool),
+	}
+}
+
+func (w *targetWatcher) watchTargetsOf(obj runtime.Object) {
+	instance, ok := obj.(*corev1alpha1.PlacementRule)
+	if !ok {
+		return
+	}
+
+	gvr, err := getTargetGVR(w.client, instance)
+	if err != nil || gvr == nil {
+		// reconcile reports the missing target, nothing to watch yet
+		return
+	}
+
+	if err = w.watch(*gvr); err != nil {
+		klog.Error("Failed to watch placement targets ", gvr.String(), " with error: ", err)
+	}
+}
+
+func (w *targetWatcher) watch(gvr schema.GroupVersionResource) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.watched[gvr] {
+		return nil
+	}
+
+	gvk, err := w.mapper.KindFor(gvr)
+	if err != nil {
+		return err
+	}
+
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(gvk)
+
+	err = w.controller.Watch(&source.Kind{Type: obj}, &targetEventHandler{client: w.client, gvr: gvr, ignored: w.ignored, rules: w.rules})
+	if err != nil {
+		return err
+	}
+
+	klog.Info("Watching placement targets ", gvr.String())
+
+	w.watched[gvr] = true
+
+	return nil
+}
+
+// ruleTargetHandler starts the target watch for PlacementRules, requests are enqueued by EnqueueRequestForObject
+type ruleTargetHandler struct {
+	watcher *targetWatcher
+}
+
+var _ handler.EventHandler = &ruleTargetHandler{}
+
+func (h *ruleTargetHandler) Create(evt event.CreateEvent, _ workqueue.RateLimitingInterface) {
+	h.watcher.watchTargetsOf(evt.Object)
+}
+
+func (h *ruleTargetHandler) Update(evt event.UpdateEvent, _ workqueue.RateLimitingInterface) {
+	h.watcher.watchTargetsOf(evt.ObjectNew)
+}
+
+func (h *ruleTargetHandler) Delete(event.DeleteEvent, workqueue.RateLimitingInterface) {}
+
+func (h *ruleTargetHandler) Generic(evt event.GenericEvent, _ workqueue.RateLimitingInterface) {
+	h.watcher.watchTargetsOf(evt.Object)
+}
+
+// targetEventHandler enqueues the PlacementRules of gvr whose candidate list is changed by a target event:
+// either the target now passes the rule filters and is not a candidate yet, or it is a candidate and does not pass anymore
+type targetEventHandler struct {
+	client  client.Client
+	gvr     schema.GroupVersionResource
+	ignored []corev1.ObjectReference
+	rules   labels.Selector
+}
+
+var _ handler.EventHandler = &targetEventHandler{}
+
+func (h *targetEventHandler) Create(evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	h.enqueueRules(evt.Object, false, q)
+}
+
+func (h *targetEventHandler) Update(evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	h.enqueueRules(evt.ObjectNew, false, q)
+}
+
+func (h *targetEventHandler) Delete(evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	h.enqueueRules(evt.Object, true, q)
+}
+
+func (h *targetEventHandler) Generic(evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	h.enqueueRules(evt.Object, false, q)
+}
+
+func (h *targetEventHandler) enqueueRules(obj runtime.Object, deleted bool, q workqueue.RateLimitingInterface) {
+	target, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+
+	rules, err := h.rulesOfTargets()
+	if err != nil {
+		klog.Error("Failed to list placement rules for target ", target.GetNamespace()+"/"+target.GetName(), " with error: ", err)
+		return
+	}
+
+	for i := range rules {
+		instance := &rules[i]
+
+		gvr, err := getTargetGVR(h.client, instance)
+		if err != nil || gvr == nil || *gvr != h.gvr {
+			continue
+		}
+
+		candidate := false
+
+		if !deleted {
+			candidate, err = isCandidate(instance, gvr, target, h.ignored)
+			if err != nil {
+				continue
+			}
+		}
+
+		if candidate == isKnownCandidate(instance, target.GetUID()) && !h.fallbackChanged(instance, gvr, target, deleted) {
+			continue
+		}
+
+		klog.Info("Target ", target.GetNamespace()+"/"+target.GetName(), " changed candidates of placement rule ",
+			instance.Namespace+"/"+instance.Name)
+
+		q.Add(reconcile.Request{NamespacedName: types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace}})
+	}
+}
+
+// rulesOfTargets lists the rules placed on targets of gvr through the deployer type index: rules without deployerType
+// are placed on the default target, the others on the target of the deployers of their type
+func (h *targetEventHandler) rulesOfTargets() ([]corev1alpha1.PlacementRule, error) {
+	dplytypes := make(map[string]bool)
+
+	if *convertMetaGVRToScheme(corev1alpha1.DefaultKubernetesPlacementTarget) == h.gvr {
+		dplytypes[noDeployerType] = true
+	}
+
+	dplylist := &corev1alpha1.DeployerList{}
+	if err := h.client.List(context.TODO(), dplylist); err != nil {
+		return nil, err
+	}
+
+	for _, dply := range dplylist.Items {
+		target := corev1alpha1.DeployerPlacementTarget
+		if dply.Spec.PlacementTarget != nil {
+			target = dply.Spec.PlacementTarget
+		}
+
+		if *convertMetaGVRToScheme(target) == h.gvr {
+			dplytypes[dply.Spec.Type] = true
+		}
+	}
+
+	var rules []corev1alpha1.PlacementRule
+
+	for dplytype := range dplytypes {
+		rl := &corev1alpha1.PlacementRuleList{}
+
+		err := h.client.List(context.TODO(), rl, client.MatchingFields{ruleDeployerTypeIndex: dplytype},
+			client.MatchingLabelsSelector{Selector: h.rules})
+		if err != nil {
+			return nil, err
+		}
+
+		rules = append(rules, rl.Items...)
+	}
+
+	return rules, nil
+}
+
+// fallbackChanged returns true if a target event changes the fallback targets of the rule
+func (h *targetEventHandler) fallbackChanged(instance *corev1alpha1.PlacementRule, gvr *schema.GroupVersionResource,
+	target *unstructured.Unstructured, deleted bool) bool {
+	if instance.Spec.Fallback == nil {
+		return false
+	}
+
+	fallback := false
+
+	if !deleted {
+		var err error
+
+		fallback, err = isFallbackTarget(instance, gvr, target, h.ignored)
+		if err != nil {
+			return false
+		}
+	}
+
+	return fallback != isKnownFallbackTarget(instance, target.GetUID())
+}
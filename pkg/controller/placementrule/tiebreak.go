@@ -0,0 +1,110 @@
+// Copyright 2019 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package placementrule
+
+import (
+	"hash/fnv"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/klog"
+
+	advisorutils "github.com/hybridapp-io/ham-placement/pkg/advisor/utils"
+	corev1alpha1 "github.com/hybridapp-io/ham-placement/pkg/apis/core/v1alpha1"
+)
+
+// tieBreaker orders candidates with the same score by the tie-break policy of a rule, whatever the list order is.
+// Every policy falls back to namespace/name, so that the order is total.
+type tieBreaker struct {
+	policy corev1alpha1.TieBreakPolicy
+	seed   string
+	ages   map[string]metav1.Time
+}
+
+func newTieBreaker(instance *corev1alpha1.PlacementRule, candidates []corev1.ObjectReference, targets *targetCache) *tieBreaker {
+	tb := &tieBreaker{
+		policy: corev1alpha1.TieBreakByName,
+		seed:   instance.Namespace + "/" + instance.Name,
+	}
+
+	if instance.Spec.TieBreak != nil {
+		tb.policy = *instance.Spec.TieBreak
+	}
+
+	if tb.policy != corev1alpha1.TieBreakByAge {
+		return tb
+	}
+
+	tb.ages = make(map[string]metav1.Time)
+
+	if targets == nil {
+		klog.Warning("No target cache to tie-break placement rule ", tb.seed, " by age, falling back to name")
+		return tb
+	}
+
+	for _, or := range candidates {
+		obj, err := targets.get(or)
+		if err != nil {
+			klog.Warning("Failed to get target ", or.Namespace+"/"+or.Name, " to tie-break by age with error: ", err)
+			continue
+		}
+
+		tb.ages[advisorutils.GenKey(or)] = obj.GetCreationTimestamp()
+	}
+
+	return tb
+}
+
+// prefer returns true if candidate a is kept over candidate b with the same score
+func (tb *tieBreaker) prefer(a, b corev1.ObjectReference) bool {
+	switch tb.policy {
+	case corev1alpha1.TieBreakByUID:
+		if ha, hb := hash(string(a.UID)), hash(string(b.UID)); ha != hb {
+			return ha < hb
+		}
+	case corev1alpha1.TieBreakByHash:
+		if ha, hb := hash(tb.seed+"/"+string(a.UID)), hash(tb.seed+"/"+string(b.UID)); ha != hb {
+			return ha < hb
+		}
+	case corev1alpha1.TieBreakByAge:
+		agea, oka := tb.ages[advisorutils.GenKey(a)]
+		ageb, okb := tb.ages[advisorutils.GenKey(b)]
+
+		if oka != okb {
+			return oka
+		}
+
+		if !agea.Equal(&ageb) {
+			return agea.Before(&ageb)
+		}
+	}
+
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+
+	if a.Name != b.Name {
+		return a.Name < b.Name
+	}
+
+	return a.UID < b.UID
+}
+
+func hash(s string) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(s))
+
+	return h.Sum64()
+}